@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/idea456/development-trains/pkg/graph"
+)
+
+// SourcePosition records where a raw entry was read from, either a line and column in a text file or a path in a JSON document
+type SourcePosition struct {
+	File       string
+	Line       int
+	Column     int
+	Path       string
+	FieldPaths []string // JSON path of each comma separated field of the entry
+}
+
+// Positions returns the source positions recorded for a section of the raw input
+func (input *RawInput) Positions(section graph.InputSection) []SourcePosition {
+	switch section {
+	case graph.StationSection:
+		return input.StationPositions
+	case graph.RouteSection:
+		return input.RoutePositions
+	case graph.PackageSection:
+		return input.PackagePositions
+	case graph.IncompatibilitySection:
+		return input.IncompatibilityPositions
+	default:
+		return input.TrainPositions
+	}
+}
+
+// Entries returns the raw entries of a section of the raw input
+func (input *RawInput) Entries(section graph.InputSection) []string {
+	switch section {
+	case graph.StationSection:
+		return input.RawStations
+	case graph.RouteSection:
+		return input.RawRoutes
+	case graph.PackageSection:
+		return input.RawPackages
+	case graph.IncompatibilitySection:
+		return input.RawIncompatibilities
+	default:
+		return input.RawTrains
+	}
+}
+
+// Locate formats a validation issue with the position it was found at, e.g. tests/sample.txt:8:7: unknown station "Z"
+// Falls back to the section and index of the entry if the input has no recorded positions, such as prompted input
+func (input *RawInput) Locate(issue graph.InputIssue) string {
+	positions := input.Positions(issue.Section)
+	if issue.Index >= len(positions) {
+		return issue.Error()
+	}
+
+	position := positions[issue.Index]
+	if position.File == "" && position.Path == "" {
+		return issue.Error()
+	}
+	if position.Path != "" {
+		path := position.Path
+		if issue.Field >= 0 && issue.Field < len(position.FieldPaths) {
+			path = position.FieldPaths[issue.Field]
+		}
+		return fmt.Sprintf("%s: %s: %s", position.File, path, issue.Message)
+	}
+
+	column := position.Column
+	if issue.Field > 0 {
+		fields := strings.Split(input.Entries(issue.Section)[issue.Index], ",")
+		for _, field := range fields[:min(issue.Field, len(fields))] {
+			column += len(field) + 1
+		}
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", position.File, position.Line, column, issue.Message)
+}
+
+// Validate runs the graph input validation and returns every issue formatted with its source position
+func (input *RawInput) Validate() []string {
+	diagnostics := make([]string, 0)
+	for _, issue := range graph.ValidateInput(input.RawStations, input.RawRoutes, input.RawPackages, input.RawTrains, input.RawIncompatibilities) {
+		diagnostics = append(diagnostics, input.Locate(issue))
+	}
+	return diagnostics
+}
@@ -0,0 +1,427 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/idea456/development-trains/pkg/graph"
+)
+
+// dotToken is a lexical token of a Graphviz DOT file
+type dotToken struct {
+	Kind   string // "id" for identifiers, numerals and quoted strings, the token text itself for punctuation and edge operators, "eof" at the end
+	Text   string
+	Quoted bool
+	Line   int
+	Column int
+}
+
+// isKeyword checks if the token is the given DOT keyword, keywords are case insensitive and never quoted
+func (token dotToken) isKeyword(keyword string) bool {
+	return token.Kind == "id" && !token.Quoted && strings.EqualFold(token.Text, keyword)
+}
+
+// tokenizeDOT splits a DOT file into tokens, skipping comments and whitespace
+func tokenizeDOT(text string) ([]dotToken, error) {
+	runes := []rune(text)
+	tokens := make([]dotToken, 0)
+	line, column := 1, 1
+	i := 0
+	advance := func() {
+		if runes[i] == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+		i++
+	}
+	isIdRune := func(r rune) bool {
+		return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || r >= 0x80
+	}
+
+	for i < len(runes) {
+		r := runes[i]
+		startLine, startColumn := line, column
+		switch {
+		case unicode.IsSpace(r):
+			advance()
+		case r == '#' && startColumn == 1:
+			// preprocessor output lines are ignored
+			for i < len(runes) && runes[i] != '\n' {
+				advance()
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				advance()
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			advance()
+			advance()
+			for i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/') {
+				advance()
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("%d:%d: unterminated comment", startLine, startColumn)
+			}
+			advance()
+			advance()
+		case r == '-' && i+1 < len(runes) && (runes[i+1] == '-' || runes[i+1] == '>'):
+			operator := string(runes[i : i+2])
+			advance()
+			advance()
+			tokens = append(tokens, dotToken{Kind: operator, Text: operator, Line: startLine, Column: startColumn})
+		case strings.ContainsRune("{}[]=;,:", r):
+			advance()
+			tokens = append(tokens, dotToken{Kind: string(r), Text: string(r), Line: startLine, Column: startColumn})
+		case r == '"':
+			advance()
+			var value strings.Builder
+			for i < len(runes) && runes[i] != '"' {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					advance()
+					// escaped newlines continue the string on the next line
+					if runes[i] != '\n' {
+						if runes[i] != '"' {
+							value.WriteRune('\\')
+						}
+						value.WriteRune(runes[i])
+					}
+					advance()
+					continue
+				}
+				value.WriteRune(runes[i])
+				advance()
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("%d:%d: unterminated string", startLine, startColumn)
+			}
+			advance()
+			tokens = append(tokens, dotToken{Kind: "id", Text: value.String(), Quoted: true, Line: startLine, Column: startColumn})
+		case r == '-' || r == '.' || isIdRune(r):
+			var value strings.Builder
+			value.WriteRune(r)
+			advance()
+			for i < len(runes) && (isIdRune(runes[i]) || runes[i] == '.') {
+				value.WriteRune(runes[i])
+				advance()
+			}
+			tokens = append(tokens, dotToken{Kind: "id", Text: value.String(), Line: startLine, Column: startColumn})
+		case r == '<':
+			return nil, fmt.Errorf("%d:%d: HTML strings are not supported", startLine, startColumn)
+		default:
+			return nil, fmt.Errorf("%d:%d: unexpected character %q", startLine, startColumn, r)
+		}
+	}
+	tokens = append(tokens, dotToken{Kind: "eof", Text: "end of file", Line: line, Column: column})
+	return tokens, nil
+}
+
+// dotParser builds the raw input from the tokens of a DOT file
+type dotParser struct {
+	path     string
+	tokens   []dotToken
+	next     int
+	directed bool
+	input    *RawInput
+	stations map[string]bool
+}
+
+func (p *dotParser) peek() dotToken {
+	return p.tokens[p.next]
+}
+
+func (p *dotParser) take() dotToken {
+	token := p.tokens[p.next]
+	if token.Kind != "eof" {
+		p.next++
+	}
+	return token
+}
+
+func (p *dotParser) errorAt(token dotToken, format string, args ...any) error {
+	return fmt.Errorf("%s:%d:%d: %s", p.path, token.Line, token.Column, fmt.Sprintf(format, args...))
+}
+
+func (p *dotParser) expect(kind string) (dotToken, error) {
+	token := p.take()
+	if token.Kind != kind {
+		return token, p.errorAt(token, "expected %s, found %s", kind, token.Text)
+	}
+	return token, nil
+}
+
+// addStation adds a node as a station the first time it is seen
+func (p *dotParser) addStation(token dotToken) error {
+	if p.stations[token.Text] {
+		return nil
+	}
+	if strings.Contains(token.Text, ",") {
+		return p.errorAt(token, "node %q cannot be used as a station name since it contains a comma", token.Text)
+	}
+	p.stations[token.Text] = true
+	p.input.RawStations = append(p.input.RawStations, token.Text)
+	p.input.StationPositions = append(p.input.StationPositions, SourcePosition{File: p.path, Line: token.Line, Column: token.Column})
+	return nil
+}
+
+// parseAttributes parses any number of [key=value, ...] lists into the given attributes, keeping the value tokens for their positions
+func (p *dotParser) parseAttributes(attributes map[string]dotToken) error {
+	for p.peek().Kind == "[" {
+		p.take()
+		for p.peek().Kind != "]" {
+			key, err := p.expect("id")
+			if err != nil {
+				return err
+			}
+			if _, err := p.expect("="); err != nil {
+				return err
+			}
+			value, err := p.expect("id")
+			if err != nil {
+				return err
+			}
+			attributes[key.Text] = value
+			if p.peek().Kind == "," || p.peek().Kind == ";" {
+				p.take()
+			}
+		}
+		p.take()
+	}
+	return nil
+}
+
+// parseNodeId parses a node id, ignoring its optional port
+func (p *dotParser) parseNodeId() (dotToken, error) {
+	token := p.take()
+	if token.isKeyword("subgraph") || token.Kind == "{" {
+		return token, p.errorAt(token, "subgraphs cannot be used as edge endpoints")
+	}
+	if token.Kind != "id" {
+		return token, p.errorAt(token, "expected a node, found %s", token.Text)
+	}
+	for p.peek().Kind == ":" {
+		p.take()
+		if _, err := p.expect("id"); err != nil {
+			return token, err
+		}
+	}
+	return token, nil
+}
+
+// parseStatements parses statements until the closing brace of the current graph or subgraph
+// Default edge attributes only apply within the subgraph they are set in
+func (p *dotParser) parseStatements(edgeDefaults map[string]dotToken) error {
+	for {
+		token := p.peek()
+		switch {
+		case token.Kind == "}":
+			p.take()
+			return nil
+		case token.Kind == "eof":
+			return p.errorAt(token, "expected }, found end of file")
+		case token.Kind == ";":
+			p.take()
+		case token.isKeyword("graph") || token.isKeyword("node"):
+			p.take()
+			if err := p.parseAttributes(map[string]dotToken{}); err != nil {
+				return err
+			}
+		case token.isKeyword("edge"):
+			p.take()
+			if err := p.parseAttributes(edgeDefaults); err != nil {
+				return err
+			}
+		case token.isKeyword("subgraph") || token.Kind == "{":
+			p.take()
+			if token.isKeyword("subgraph") {
+				if p.peek().Kind == "id" {
+					p.take()
+				}
+				if _, err := p.expect("{"); err != nil {
+					return err
+				}
+			}
+			scopedDefaults := make(map[string]dotToken, len(edgeDefaults))
+			for key, value := range edgeDefaults {
+				scopedDefaults[key] = value
+			}
+			if err := p.parseStatements(scopedDefaults); err != nil {
+				return err
+			}
+		default:
+			if err := p.parseNodeOrEdge(edgeDefaults); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// parseNodeOrEdge parses a node statement, a chain of edges or a graph attribute assignment
+func (p *dotParser) parseNodeOrEdge(edgeDefaults map[string]dotToken) error {
+	first, err := p.parseNodeId()
+	if err != nil {
+		return err
+	}
+	// graph attribute assignment, e.g. rankdir=LR
+	if p.peek().Kind == "=" {
+		p.take()
+		_, err := p.expect("id")
+		return err
+	}
+
+	nodes := []dotToken{first}
+	operators := make([]dotToken, 0)
+	for p.peek().Kind == "--" || p.peek().Kind == "->" {
+		operator := p.take()
+		if p.directed && operator.Kind == "--" {
+			return p.errorAt(operator, "undirected edge -- used in a digraph, use ->")
+		}
+		if !p.directed && operator.Kind == "->" {
+			return p.errorAt(operator, "directed edge -> used in a graph, use -- or a digraph")
+		}
+		node, err := p.parseNodeId()
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, node)
+		operators = append(operators, operator)
+	}
+
+	attributes := make(map[string]dotToken, len(edgeDefaults))
+	for key, value := range edgeDefaults {
+		attributes[key] = value
+	}
+	if err := p.parseAttributes(attributes); err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if err := p.addStation(node); err != nil {
+			return err
+		}
+	}
+	if len(operators) == 0 {
+		return nil
+	}
+	return p.addRoutes(nodes, operators, attributes)
+}
+
+// addRoutes adds a route for every edge of a chain of edges, using the minutes, dir and name attributes
+// The attributes are joined into the raw route fields, so they are checked here for anything that would change how the fields are read
+func (p *dotParser) addRoutes(nodes []dotToken, operators []dotToken, attributes map[string]dotToken) error {
+	minutesToken, exists := attributes["minutes"]
+	if !exists {
+		return p.errorAt(operators[0], "edge %s %s %s is missing the minutes attribute", nodes[0].Text, operators[0].Text, nodes[1].Text)
+	}
+	minutes := strings.TrimSpace(minutesToken.Text)
+	if strings.ContainsAny(minutes, ",= \t") {
+		return p.errorAt(minutesToken, "minutes must be a single travel time, e.g. 90 or 1h30m, found %q", minutesToken.Text)
+	}
+	if _, err := graph.ParseTravelTime(minutes); err != nil {
+		return p.errorAt(minutesToken, "minutes: %v", err)
+	}
+	nameToken, hasName := attributes["name"]
+	name := nameToken.Text
+	if hasName && len(operators) > 1 {
+		return p.errorAt(operators[0], "the name attribute cannot be used on a chain of edges, since route names must be unique")
+	}
+	if hasName && strings.ContainsAny(name, ",=") {
+		return p.errorAt(nameToken, "name %q cannot be used as a route name since it contains a comma or an equals sign", name)
+	}
+
+	// edges of a digraph are one-way by default, edges of a graph are bidirectional by default
+	direction := "none"
+	if p.directed {
+		direction = "forward"
+	}
+	if dir, exists := attributes["dir"]; exists {
+		direction = dir.Text
+	}
+	if direction != "none" && direction != "both" && direction != "forward" && direction != "back" {
+		return p.errorAt(operators[0], "dir must be one of forward, back, both or none, found %q", direction)
+	}
+
+	for i, operator := range operators {
+		from, to := nodes[i].Text, nodes[i+1].Text
+		if direction == "back" {
+			from, to = to, from
+		}
+		routeName := name
+		if !hasName {
+			routeName = from + "-" + to
+		}
+		fields := []string{routeName, from, to, minutes}
+		if direction == "forward" || direction == "back" {
+			fields = append(fields, graph.OneWayMarker)
+		}
+		p.input.RawRoutes = append(p.input.RawRoutes, strings.Join(fields, ","))
+		p.input.RoutePositions = append(p.input.RoutePositions, SourcePosition{File: p.path, Line: operator.Line, Column: operator.Column})
+	}
+	return nil
+}
+
+func ScanDOTFile(inputFilePath string) (*RawInput, error) {
+	file, err := os.ReadFile(inputFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDOT(inputFilePath, string(file))
+}
+
+/*
+ParseDOT imports the stations and routes of a rail network drawn as a Graphviz DOT graph:
+
+	graph network {
+		edge [minutes=10]
+		A -- B [name=E1]
+		B -- C -- D [minutes=30]
+		D -- E [dir=forward]
+	}
+
+Every node becomes a station named after its id, and every edge becomes a route taking the number of minutes given by its minutes attribute
+Routes are named after their name attribute, or <from>-<to> if it is missing
+Edges of a graph are bidirectional unless their dir attribute is forward or back, edges of a digraph are one-way unless their dir attribute is both or none
+*/
+func ParseDOT(inputFilePath string, text string) (*RawInput, error) {
+	tokens, err := tokenizeDOT(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", inputFilePath, err)
+	}
+	p := &dotParser{
+		path:     inputFilePath,
+		tokens:   tokens,
+		input:    &RawInput{},
+		stations: make(map[string]bool, 0),
+	}
+
+	if p.peek().isKeyword("strict") {
+		p.take()
+	}
+	header := p.take()
+	switch {
+	case header.isKeyword("graph"):
+	case header.isKeyword("digraph"):
+		p.directed = true
+	default:
+		return nil, p.errorAt(header, "expected graph or digraph, found %s", header.Text)
+	}
+	if p.peek().Kind == "id" {
+		p.take()
+	}
+	if _, err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	if err := p.parseStatements(map[string]dotToken{}); err != nil {
+		return nil, err
+	}
+	if token := p.peek(); token.Kind != "eof" {
+		return nil, p.errorAt(token, "unexpected %s after the end of the graph", token.Text)
+	}
+	if len(p.input.RawStations) == 0 {
+		return nil, errors.New(inputFilePath + ": the graph has no nodes")
+	}
+	return p.input, nil
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/idea456/development-trains/pkg/graph"
+)
+
+// gtfsTable is a GTFS csv file read into rows keyed by column name
+type gtfsTable struct {
+	Path string
+	Rows []map[string]string
+	Line []int // line of each row in the file, used for error messages
+}
+
+// readGTFSTable reads a GTFS csv file, checking that the required columns are present
+func readGTFSTable(path string, requiredColumns ...string) (*gtfsTable, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%s: unable to read header: %v", path, err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+	}
+	for _, column := range requiredColumns {
+		if !slices.Contains(header, column) {
+			return nil, fmt.Errorf("%s: missing required column %q", path, column)
+		}
+	}
+
+	table := &gtfsTable{Path: path}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		line, _ := reader.FieldPos(0)
+		row := make(map[string]string, len(header))
+		for i, column := range header {
+			if i < len(record) {
+				row[column] = strings.TrimSpace(record[i])
+			}
+		}
+		table.Rows = append(table.Rows, row)
+		table.Line = append(table.Line, line)
+	}
+	return table, nil
+}
+
+// parseGTFSTime parses a GTFS time of the form HH:MM:SS into seconds, hours can go past 24 for trips running past midnight
+func parseGTFSTime(raw string) (int, error) {
+	parts := strings.Split(raw, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("%q is not a valid time, expected HH:MM:SS", raw)
+	}
+	seconds := 0
+	for _, part := range parts {
+		value, err := strconv.Atoi(part)
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("%q is not a valid time, expected HH:MM:SS", raw)
+		}
+		seconds = seconds*60 + value
+	}
+	return seconds, nil
+}
+
+// gtfsStopTime is a single stop of a trip
+type gtfsStopTime struct {
+	StationName string
+	Sequence    int
+	Arrival     int
+	Departure   int
+	Line        int
+}
+
+// gtfsHop is the fastest observed travel between 2 consecutive stops in one direction
+type gtfsHop struct {
+	Minutes int
+	Line    int
+}
+
+/*
+ScanGTFSDirectory imports the station network from a GTFS static feed directory, reading its stops.txt, trips.txt and stop_times.txt
+Every stop becomes a station named after its stop_id, platforms with a parent_station are merged into their parent station
+Parent stations are followed up to the stop without a parent, e.g. a boarding area is merged into the station of its platform
+Every pair of consecutive stops in a trip becomes a route, with the minimum travel time observed across all trips rounded up to whole minutes
+Routes observed in both directions with the same travel time are bidirectional, otherwise a one-way route is created for each observed direction
+*/
+func ScanGTFSDirectory(directory string) (*RawInput, error) {
+	stops, err := readGTFSTable(filepath.Join(directory, "stops.txt"), "stop_id")
+	if err != nil {
+		return nil, err
+	}
+	trips, err := readGTFSTable(filepath.Join(directory, "trips.txt"), "trip_id")
+	if err != nil {
+		return nil, err
+	}
+	stopTimes, err := readGTFSTable(filepath.Join(directory, "stop_times.txt"), "trip_id", "stop_id", "stop_sequence", "arrival_time", "departure_time")
+	if err != nil {
+		return nil, err
+	}
+
+	input := &RawInput{}
+	parentStations := make(map[string]string, len(stops.Rows))
+	for i, stop := range stops.Rows {
+		if stop["parent_station"] != "" {
+			parentStations[stop["stop_id"]] = stop["parent_station"]
+			continue
+		}
+		input.RawStations = append(input.RawStations, stop["stop_id"])
+		input.StationPositions = append(input.StationPositions, SourcePosition{File: stops.Path, Line: stops.Line[i], Column: 1})
+	}
+
+	errs := make([]error, 0)
+	stationNames := make(map[string]string, len(stops.Rows))
+	for i, stop := range stops.Rows {
+		stationName := stop["stop_id"]
+		followed := []string{stationName}
+		for parentStations[stationName] != "" {
+			stationName = parentStations[stationName]
+			if slices.Contains(followed, stationName) {
+				errs = append(errs, fmt.Errorf("%s:%d: stop %q has a parent_station cycle %s -> %s", stops.Path, stops.Line[i], stop["stop_id"], strings.Join(followed, " -> "), stationName))
+				break
+			}
+			followed = append(followed, stationName)
+		}
+		stationNames[stop["stop_id"]] = stationName
+	}
+
+	tripIds := make(map[string]bool, len(trips.Rows))
+	for _, trip := range trips.Rows {
+		tripIds[trip["trip_id"]] = true
+	}
+
+	stopTimesByTrip := make(map[string][]gtfsStopTime, 0)
+	tripOrder := make([]string, 0)
+	for i, row := range stopTimes.Rows {
+		location := fmt.Sprintf("%s:%d", stopTimes.Path, stopTimes.Line[i])
+		if !tripIds[row["trip_id"]] {
+			errs = append(errs, fmt.Errorf("%s: trip %q is not defined in trips.txt", location, row["trip_id"]))
+			continue
+		}
+		stationName, exists := stationNames[row["stop_id"]]
+		if !exists {
+			errs = append(errs, fmt.Errorf("%s: stop %q is not defined in stops.txt", location, row["stop_id"]))
+			continue
+		}
+		sequence, err := strconv.Atoi(row["stop_sequence"])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: stop_sequence %q is not an integer", location, row["stop_sequence"]))
+			continue
+		}
+		// GTFS allows leaving the times of stops between timepoints empty, those stops are skipped
+		if row["arrival_time"] == "" || row["departure_time"] == "" {
+			continue
+		}
+		arrival, err := parseGTFSTime(row["arrival_time"])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: arrival_time %v", location, err))
+			continue
+		}
+		departure, err := parseGTFSTime(row["departure_time"])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: departure_time %v", location, err))
+			continue
+		}
+
+		if _, exists := stopTimesByTrip[row["trip_id"]]; !exists {
+			tripOrder = append(tripOrder, row["trip_id"])
+		}
+		stopTimesByTrip[row["trip_id"]] = append(stopTimesByTrip[row["trip_id"]], gtfsStopTime{
+			StationName: stationName,
+			Sequence:    sequence,
+			Arrival:     arrival,
+			Departure:   departure,
+			Line:        stopTimes.Line[i],
+		})
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	// find the fastest observed hop between every pair of consecutive stations, keeping the order they were first seen in
+	type hopKey struct{ from, to string }
+	hops := make(map[hopKey]gtfsHop, 0)
+	hopOrder := make([]hopKey, 0)
+	for _, tripId := range tripOrder {
+		tripStopTimes := stopTimesByTrip[tripId]
+		slices.SortStableFunc(tripStopTimes, func(a gtfsStopTime, b gtfsStopTime) int {
+			return a.Sequence - b.Sequence
+		})
+		for i := 0; i < len(tripStopTimes)-1; i++ {
+			from, to := tripStopTimes[i], tripStopTimes[i+1]
+			if from.StationName == to.StationName {
+				continue
+			}
+			seconds := to.Arrival - from.Departure
+			if seconds < 0 {
+				return nil, fmt.Errorf("%s:%d: trip %q arrives at stop %s before departing the previous stop", stopTimes.Path, to.Line, tripId, to.StationName)
+			}
+			// round up to whole minutes, a route always takes at least a minute
+			minutes := max((seconds+59)/60, 1)
+
+			key := hopKey{from.StationName, to.StationName}
+			hop, exists := hops[key]
+			if !exists {
+				hopOrder = append(hopOrder, key)
+			}
+			if !exists || minutes < hop.Minutes {
+				hops[key] = gtfsHop{Minutes: minutes, Line: to.Line}
+			}
+		}
+	}
+
+	bidirectional := make(map[hopKey]bool, 0)
+	for _, key := range hopOrder {
+		// already added as a bidirectional route when the reverse direction was seen first
+		if bidirectional[key] {
+			continue
+		}
+		hop := hops[key]
+		reverseKey := hopKey{key.to, key.from}
+		reverse, hasReverse := hops[reverseKey]
+		routeName := key.from + "-" + key.to
+		if hasReverse && reverse.Minutes == hop.Minutes {
+			bidirectional[reverseKey] = true
+			input.RawRoutes = append(input.RawRoutes, strings.Join([]string{routeName, key.from, key.to, strconv.Itoa(hop.Minutes)}, ","))
+		} else {
+			input.RawRoutes = append(input.RawRoutes, strings.Join([]string{routeName, key.from, key.to, strconv.Itoa(hop.Minutes), graph.OneWayMarker}, ","))
+		}
+		input.RoutePositions = append(input.RoutePositions, SourcePosition{File: stopTimes.Path, Line: hop.Line, Column: 1})
+	}
+
+	return input, nil
+}
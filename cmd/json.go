@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/idea456/development-trains/pkg/graph"
+)
+
+// JSONSchemaError represents a problem found in a JSON input file, located by its JSON path, e.g. orders.json: $.routes[1].travelTime
+type JSONSchemaError struct {
+	File    string
+	Path    string
+	Message string
+}
+
+func (e *JSONSchemaError) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("%s: %s: %s", e.File, e.Path, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// jsonField describes a single expected key of a JSON object and where its value should be decoded into
+type jsonField struct {
+	Key      string
+	Target   any
+	Required bool
+}
+
+// decodeJSONObject decodes a raw JSON object into the given fields, reporting missing, unknown and mistyped keys with their JSON paths
+func decodeJSONObject(path string, raw json.RawMessage, fields []jsonField) []error {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &object); err != nil || object == nil {
+		return []error{&JSONSchemaError{Path: path, Message: fmt.Sprintf("expected an object, got %s", jsonKind(raw))}}
+	}
+
+	errs := make([]error, 0)
+	knownKeys := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		knownKeys[field.Key] = true
+		value, exists := object[field.Key]
+		if !exists {
+			if field.Required {
+				errs = append(errs, &JSONSchemaError{Path: path, Message: fmt.Sprintf("missing required field %q", field.Key)})
+			}
+			continue
+		}
+		if err := json.Unmarshal(value, field.Target); err != nil {
+			errs = append(errs, &JSONSchemaError{Path: path + "." + field.Key, Message: jsonTypeMessage(err)})
+			continue
+		}
+		errs = append(errs, checkJSONSeparators(path+"."+field.Key, field.Target)...)
+	}
+
+	// go returns the keys in random order, sort the unknown keys to make the errors determinstic
+	unknownKeys := make([]string, 0)
+	for key := range object {
+		if !knownKeys[key] {
+			unknownKeys = append(unknownKeys, key)
+		}
+	}
+	slices.Sort(unknownKeys)
+	for _, key := range unknownKeys {
+		errs = append(errs, &JSONSchemaError{Path: path + "." + key, Message: "unknown field"})
+	}
+	return errs
+}
+
+// jsonFieldSeparators are the characters separating the fields and attributes of a raw entry, which decoded values must not contain
+const jsonFieldSeparators = ",="
+
+// checkJSONSeparators reports decoded strings containing a field separator, since they would shift the fields of the raw entry they end up in
+func checkJSONSeparators(path string, target any) []error {
+	var values []string
+	var paths []string
+	switch value := target.(type) {
+	case *string:
+		values, paths = []string{*value}, []string{path}
+	case *jsonQuantity:
+		values, paths = []string{string(*value)}, []string{path}
+	case *[]string:
+		for i, element := range *value {
+			values = append(values, element)
+			paths = append(paths, fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+
+	errs := make([]error, 0)
+	for i, value := range values {
+		if strings.ContainsAny(value, jsonFieldSeparators) {
+			errs = append(errs, &JSONSchemaError{Path: paths[i], Message: fmt.Sprintf("%q must not contain a comma or an equals sign", value)})
+		}
+	}
+	return errs
+}
+
+// jsonTypeMessage turns a decoding error into a short human readable message
+func jsonTypeMessage(err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Sprintf("expected %s, got %s", jsonTypeName(typeErr.Type), typeErr.Value)
+	}
+	return err.Error()
+}
+
+// jsonTypeName returns the JSON type name expected when decoding into a Go type
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	default:
+		return t.Kind().String()
+	}
+}
+
+// jsonKind returns the JSON type name of a raw value
+func jsonKind(raw json.RawMessage) string {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 {
+		return "nothing"
+	}
+	switch trimmed[0] {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "boolean"
+	case 'n':
+		return "null"
+	default:
+		return "number"
+	}
+}
+
+// jsonQuantity is a weight, capacity, volume or travel time given either as a plain number or as a string with a unit, e.g. 500 or "2.5t"
+// It is kept as written and converted by the graph parser like the text format
+type jsonQuantity string
+
+func (quantity *jsonQuantity) UnmarshalJSON(data []byte) error {
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err == nil {
+		*quantity = jsonQuantity(number.String())
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*quantity = jsonQuantity(text)
+		return nil
+	}
+	return fmt.Errorf("expected a number or a string with a unit such as \"2.5t\" or \"1h30m\", got %s", jsonKind(data))
+}
+
+type jsonStation struct {
+	Name         string
+	HandlingTime jsonQuantity
+	HandlingRate jsonQuantity
+	Platforms    jsonQuantity
+}
+
+type jsonRoute struct {
+	Name       string
+	From       string
+	To         string
+	TravelTime jsonQuantity
+	OneWay     bool
+	Capacity   jsonQuantity
+	Track      string
+	MaxSpeed   jsonQuantity
+	MaxLoad    jsonQuantity
+	Closures   []string
+}
+
+type jsonPackage struct {
+	Name      string
+	Weight    jsonQuantity
+	From      string
+	To        string
+	ReadyTime jsonQuantity
+	DueTime   jsonQuantity
+	Priority  string
+	Volume    jsonQuantity
+	Category  string
+}
+
+type jsonTrain struct {
+	Name         string
+	Capacity     jsonQuantity
+	Start        string
+	CouplingTime jsonQuantity
+	Speed        jsonQuantity
+	Volume       jsonQuantity
+	Depot        string
+	DutyLimit    jsonQuantity
+	ShiftLimit   jsonQuantity
+	RestTime     jsonQuantity
+}
+
+// ScanInputJSONFile reads a JSON problem definition of the form:
+// {"stations": [{"name": "A"}], "routes": [{"name": "E1", "from": "A", "to": "B", "travelTime": 30, "oneWay": false}], "packages": [{"name": "K1", "weight": 5, "from": "A", "to": "B"}], "trains": [{"name": "Q1", "capacity": 6, "start": "B"}]}
+// and converts it to the same raw input used by the text format
+// Packages can also have an optional "readyTime" and "dueTime", in minutes or with a unit like the travel times, a "priority" class, a "volume" and a "category"
+// Incompatible package categories are listed in an optional "incompatibilities" array, e.g. [{"categories": ["hazmat", "food"]}]
+// Stations can have an optional "handlingTime", "handlingRate" and "platforms", routes a "capacity", "track", "maxSpeed", "maxLoad" and "closures", e.g. ["1h-2h"], and trains a "couplingTime", "speed", "volumeCapacity", "depot", "dutyLimit", "shiftLimit" and "restTime"
+func ScanInputJSONFile(inputFilePath string) (*RawInput, error) {
+	file, err := os.ReadFile(inputFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return ParseInputJSON(inputFilePath, file)
+}
+
+// jsonPosition records the JSON path of an entry and of each of its fields, in the order they appear in the raw entry
+func jsonPosition(file string, path string, keys ...string) SourcePosition {
+	fieldPaths := make([]string, 0, len(keys))
+	for _, key := range keys {
+		fieldPaths = append(fieldPaths, path+"."+key)
+	}
+	return SourcePosition{File: file, Path: path, FieldPaths: fieldPaths}
+}
+
+// appendJSONAttribute appends an optional value as a key=value attribute of a raw entry, along with the JSON key it was read from
+func appendJSONAttribute(fields []string, keys []string, attribute string, key string, value string) ([]string, []string) {
+	if value == "" {
+		return fields, keys
+	}
+	return append(fields, attribute+"="+value), append(keys, key)
+}
+
+// ParseInputJSON converts a JSON problem definition to raw input, every schema error found is reported at once
+func ParseInputJSON(file string, data []byte) (*RawInput, error) {
+	var document json.RawMessage
+	if err := json.Unmarshal(data, &document); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return nil, fmt.Errorf("%s: invalid JSON at byte offset %d: %v", file, syntaxErr.Offset, err)
+		}
+		return nil, fmt.Errorf("%s: invalid JSON: %v", file, err)
+	}
+
+	var rawStations, rawRoutes, rawPackages, rawTrains, rawIncompatibilities []json.RawMessage
+	errs := decodeJSONObject("$", document, []jsonField{
+		{Key: "stations", Target: &rawStations, Required: true},
+		{Key: "routes", Target: &rawRoutes, Required: true},
+		{Key: "packages", Target: &rawPackages, Required: true},
+		{Key: "trains", Target: &rawTrains, Required: true},
+		{Key: "incompatibilities", Target: &rawIncompatibilities},
+	})
+
+	input := &RawInput{}
+	for i, raw := range rawStations {
+		var station jsonStation
+		path := fmt.Sprintf("$.stations[%d]", i)
+		errs = append(errs, decodeJSONObject(path, raw, []jsonField{
+			{Key: "name", Target: &station.Name, Required: true},
+			{Key: "handlingTime", Target: &station.HandlingTime},
+			{Key: "handlingRate", Target: &station.HandlingRate},
+			{Key: "platforms", Target: &station.Platforms},
+		})...)
+		fields, keys := []string{station.Name}, []string{"name"}
+		fields, keys = appendJSONAttribute(fields, keys, "handling", "handlingTime", string(station.HandlingTime))
+		fields, keys = appendJSONAttribute(fields, keys, "handling-rate", "handlingRate", string(station.HandlingRate))
+		fields, keys = appendJSONAttribute(fields, keys, "platforms", "platforms", string(station.Platforms))
+		input.RawStations = append(input.RawStations, strings.Join(fields, ","))
+		input.StationPositions = append(input.StationPositions, jsonPosition(file, path, keys...))
+	}
+
+	for i, raw := range rawRoutes {
+		var route jsonRoute
+		path := fmt.Sprintf("$.routes[%d]", i)
+		errs = append(errs, decodeJSONObject(path, raw, []jsonField{
+			{Key: "name", Target: &route.Name, Required: true},
+			{Key: "from", Target: &route.From, Required: true},
+			{Key: "to", Target: &route.To, Required: true},
+			{Key: "travelTime", Target: &route.TravelTime, Required: true},
+			{Key: "oneWay", Target: &route.OneWay},
+			{Key: "capacity", Target: &route.Capacity},
+			{Key: "track", Target: &route.Track},
+			{Key: "maxSpeed", Target: &route.MaxSpeed},
+			{Key: "maxLoad", Target: &route.MaxLoad},
+			{Key: "closures", Target: &route.Closures},
+		})...)
+		fields := []string{route.Name, route.From, route.To, string(route.TravelTime)}
+		keys := []string{"name", "from", "to", "travelTime"}
+		if route.OneWay {
+			fields = append(fields, graph.OneWayMarker)
+			keys = append(keys, "oneWay")
+		}
+		fields, keys = appendJSONAttribute(fields, keys, "capacity", "capacity", string(route.Capacity))
+		fields, keys = appendJSONAttribute(fields, keys, "track", "track", route.Track)
+		fields, keys = appendJSONAttribute(fields, keys, "max-speed", "maxSpeed", string(route.MaxSpeed))
+		fields, keys = appendJSONAttribute(fields, keys, "max-load", "maxLoad", string(route.MaxLoad))
+		fields, keys = appendJSONAttribute(fields, keys, "closed", "closures", strings.Join(route.Closures, graph.ClosureSeparator))
+		input.RawRoutes = append(input.RawRoutes, strings.Join(fields, ","))
+		input.RoutePositions = append(input.RoutePositions, jsonPosition(file, path, keys...))
+	}
+
+	for i, raw := range rawPackages {
+		var delivery jsonPackage
+		path := fmt.Sprintf("$.packages[%d]", i)
+		errs = append(errs, decodeJSONObject(path, raw, []jsonField{
+			{Key: "name", Target: &delivery.Name, Required: true},
+			{Key: "weight", Target: &delivery.Weight, Required: true},
+			{Key: "from", Target: &delivery.From, Required: true},
+			{Key: "to", Target: &delivery.To, Required: true},
+			{Key: "readyTime", Target: &delivery.ReadyTime},
+			{Key: "dueTime", Target: &delivery.DueTime},
+			{Key: "priority", Target: &delivery.Priority},
+			{Key: "volume", Target: &delivery.Volume},
+			{Key: "category", Target: &delivery.Category},
+		})...)
+		fields := []string{delivery.Name, string(delivery.Weight), delivery.From, delivery.To}
+		keys := []string{"name", "weight", "from", "to"}
+		fields, keys = appendJSONAttribute(fields, keys, "ready", "readyTime", string(delivery.ReadyTime))
+		fields, keys = appendJSONAttribute(fields, keys, "due", "dueTime", string(delivery.DueTime))
+		fields, keys = appendJSONAttribute(fields, keys, "priority", "priority", delivery.Priority)
+		fields, keys = appendJSONAttribute(fields, keys, "volume", "volume", string(delivery.Volume))
+		fields, keys = appendJSONAttribute(fields, keys, "category", "category", delivery.Category)
+		input.RawPackages = append(input.RawPackages, strings.Join(fields, ","))
+		input.PackagePositions = append(input.PackagePositions, jsonPosition(file, path, keys...))
+	}
+
+	for i, raw := range rawTrains {
+		var train jsonTrain
+		path := fmt.Sprintf("$.trains[%d]", i)
+		errs = append(errs, decodeJSONObject(path, raw, []jsonField{
+			{Key: "name", Target: &train.Name, Required: true},
+			{Key: "capacity", Target: &train.Capacity, Required: true},
+			{Key: "start", Target: &train.Start, Required: true},
+			{Key: "couplingTime", Target: &train.CouplingTime},
+			{Key: "speed", Target: &train.Speed},
+			{Key: "volumeCapacity", Target: &train.Volume},
+			{Key: "depot", Target: &train.Depot},
+			{Key: "dutyLimit", Target: &train.DutyLimit},
+			{Key: "shiftLimit", Target: &train.ShiftLimit},
+			{Key: "restTime", Target: &train.RestTime},
+		})...)
+		fields := []string{train.Name, string(train.Capacity), train.Start}
+		keys := []string{"name", "capacity", "start"}
+		fields, keys = appendJSONAttribute(fields, keys, "coupling", "couplingTime", string(train.CouplingTime))
+		fields, keys = appendJSONAttribute(fields, keys, "speed", "speed", string(train.Speed))
+		fields, keys = appendJSONAttribute(fields, keys, "volume", "volumeCapacity", string(train.Volume))
+		fields, keys = appendJSONAttribute(fields, keys, "depot", "depot", train.Depot)
+		fields, keys = appendJSONAttribute(fields, keys, "duty", "dutyLimit", string(train.DutyLimit))
+		fields, keys = appendJSONAttribute(fields, keys, "shift", "shiftLimit", string(train.ShiftLimit))
+		fields, keys = appendJSONAttribute(fields, keys, "rest", "restTime", string(train.RestTime))
+		input.RawTrains = append(input.RawTrains, strings.Join(fields, ","))
+		input.TrainPositions = append(input.TrainPositions, jsonPosition(file, path, keys...))
+	}
+
+	for i, raw := range rawIncompatibilities {
+		var categories []string
+		path := fmt.Sprintf("$.incompatibilities[%d]", i)
+		errs = append(errs, decodeJSONObject(path, raw, []jsonField{
+			{Key: "categories", Target: &categories, Required: true},
+		})...)
+		keys := make([]string, 0, len(categories))
+		for j := range categories {
+			keys = append(keys, fmt.Sprintf("categories[%d]", j))
+		}
+		input.RawIncompatibilities = append(input.RawIncompatibilities, strings.Join(categories, ","))
+		input.IncompatibilityPositions = append(input.IncompatibilityPositions, jsonPosition(file, path, keys...))
+	}
+
+	if len(errs) > 0 {
+		// the paths are only located within the file, so the file is named too for merged inputs
+		for _, err := range errs {
+			var schemaErr *JSONSchemaError
+			if errors.As(err, &schemaErr) {
+				schemaErr.File = file
+			}
+		}
+		return nil, errors.Join(errs...)
+	}
+	return input, nil
+}
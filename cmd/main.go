@@ -1,11 +1,11 @@
 package main
 
 import (
-	"bufio"
 	"flag"
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/idea456/development-trains/pkg/graph"
@@ -16,149 +16,81 @@ type RawInput struct {
 	RawRoutes   []string
 	RawPackages []string
 	RawTrains   []string
-}
 
-func ScanInputFile(inputFilePath string) (*RawInput, error) {
-	file, err := os.ReadFile(inputFilePath)
-	if err != nil {
-		return nil, err
-	}
-	var rawStations, rawRoutes, rawPackages, rawTrains []string
-
-	scanner := bufio.NewScanner(strings.NewReader(string(file)))
-	scanner.Scan()
-	count := 0
-	fmt.Sscanf(scanner.Text(), "%d", &count)
-	for i := 0; i < count; i++ {
-		scanner.Scan()
-		text := scanner.Text()
-		if text == "" {
-			return nil, fmt.Errorf("expected %d stations to be in the input, found less stations than expected", count)
-		}
-		rawStations = append(rawStations, text)
-	}
+	// pairs of package categories that must not be carried together, an optional section after the trains
+	RawIncompatibilities []string
 
-	scanner.Scan()
-	scanner.Scan()
-	fmt.Sscanf(scanner.Text(), "%d", &count)
-	for i := 0; i < count; i++ {
-		scanner.Scan()
-		text := scanner.Text()
-		if text == "" {
-			return nil, fmt.Errorf("expected %d routes to be in the input, found less routes than expected", count)
-		}
-		rawRoutes = append(rawRoutes, text)
-	}
+	// positions of each raw entry in the source it was read from, used to report validation issues
+	StationPositions []SourcePosition
+	RoutePositions   []SourcePosition
+	PackagePositions []SourcePosition
+	TrainPositions   []SourcePosition
 
-	scanner.Scan()
-	scanner.Scan()
-	fmt.Sscanf(scanner.Text(), "%d", &count)
-	for i := 0; i < count; i++ {
-		scanner.Scan()
-		text := scanner.Text()
-		if text == "" {
-			return nil, fmt.Errorf("expected %d packages to be in the input, found less routes than expected", count)
-		}
-		rawPackages = append(rawPackages, text)
-	}
+	IncompatibilityPositions []SourcePosition
+}
 
-	scanner.Scan()
-	scanner.Scan()
-	fmt.Sscanf(scanner.Text(), "%d", &count)
-	for i := 0; i < count; i++ {
-		scanner.Scan()
-		text := scanner.Text()
-		if text == "" {
-			return nil, fmt.Errorf("expected %d trains to be in the input, found less routes than expected", count)
-		}
-		rawTrains = append(rawTrains, text)
-	}
+// stringsFlag is a flag that can be specified multiple times, collecting every value in order
+type stringsFlag []string
 
-	return &RawInput{
-		RawStations: rawStations,
-		RawRoutes:   rawRoutes,
-		RawPackages: rawPackages,
-		RawTrains:   rawTrains,
-	}, nil
+func (values *stringsFlag) String() string {
+	return strings.Join(*values, ",")
 }
 
-func ScanInputFromPrompt() (*RawInput, error) {
-	scanner := bufio.NewScanner(os.Stdin)
-	var rawStations, rawRoutes, rawPackages, rawTrains []string
-
-	// Read stations
-	fmt.Println("Enter the number of stations:")
-	scanner.Scan()
-	stationCount := 0
-	fmt.Sscanf(scanner.Text(), "%d", &stationCount)
-	fmt.Println("Enter the stations (one per line):")
-	for i := 0; i < stationCount; i++ {
-		scanner.Scan()
-		text := scanner.Text()
-		if text == "" {
-			return nil, fmt.Errorf("expected %d stations, found less stations than expected", stationCount)
-		}
-		rawStations = append(rawStations, text)
-	}
+func (values *stringsFlag) Set(value string) error {
+	*values = append(*values, value)
+	return nil
+}
 
-	// Read routes
-	fmt.Println("Enter the number of routes:")
-	scanner.Scan()
-	routeCount := 0
-	fmt.Sscanf(scanner.Text(), "%d", &routeCount)
-	fmt.Println("Enter the routes (one per line, format: E1,A,B,10):")
-	for i := 0; i < routeCount; i++ {
-		scanner.Scan()
-		text := scanner.Text()
-		if text == "" {
-			return nil, fmt.Errorf("expected %d routes, but found less routes than expected", routeCount)
-		}
-		rawRoutes = append(rawRoutes, text)
+/*
+ScanInput reads an input file in the format of its extension, see extensionFormat
+The given default format is only used for files with an unknown extension, which are read as text if it is empty
+so every file of a merged input is read in its own format
+*/
+func ScanInput(inputFilePath string, defaultFormat string) (*RawInput, error) {
+	if defaultFormat == "" {
+		defaultFormat = "text"
 	}
-
-	// Read packages
-	fmt.Println("Enter the number of packages:")
-	scanner.Scan()
-	packageCount := 0
-	fmt.Sscanf(scanner.Text(), "%d", &packageCount)
-	fmt.Println("Enter the packages (one per line, format: K1,3,A,E):")
-	for i := 0; i < packageCount; i++ {
-		scanner.Scan()
-		text := scanner.Text()
-		if text == "" {
-			return nil, fmt.Errorf("expected %d packages, but found less packages than expected", packageCount)
-		}
-		rawPackages = append(rawPackages, text)
+	format := extensionFormat(inputFilePath, defaultFormat)
+
+	switch format {
+	case "text":
+		return ScanInputFile(inputFilePath)
+	case "json":
+		return ScanInputJSONFile(inputFilePath)
+	case "dot":
+		return ScanDOTFile(inputFilePath)
+	default:
+		return nil, fmt.Errorf("unknown input format %q, expected text, json or dot", format)
 	}
+}
 
-	// Read trains
-	fmt.Println("Enter the number of trains:")
-	scanner.Scan()
-	trainCount := 0
-	fmt.Sscanf(scanner.Text(), "%d", &trainCount)
-	fmt.Println("Enter the trains (one per line, format: Q1,3,A):")
-	for i := 0; i < trainCount; i++ {
-		scanner.Scan()
-		text := scanner.Text()
-		if text == "" {
-			return nil, fmt.Errorf("expected %d trains, but found less trains than expected", trainCount)
-		}
-		rawTrains = append(rawTrains, text)
+// extensionFormat returns the input format a file extension stands for, or the given default format if the extension is unknown
+func extensionFormat(inputFilePath string, defaultFormat string) string {
+	switch strings.ToLower(filepath.Ext(inputFilePath)) {
+	case ".txt":
+		return "text"
+	case ".json":
+		return "json"
+	case ".dot", ".gv":
+		return "dot"
+	default:
+		return defaultFormat
 	}
-
-	return &RawInput{
-		RawStations: rawStations,
-		RawRoutes:   rawRoutes,
-		RawPackages: rawPackages,
-		RawTrains:   rawTrains,
-	}, nil
 }
 
 func main() {
-	inputFilePath := flag.String("i", "", "Path to the input file")
+	format := flag.String("format", "", "Format of the input files without a .txt, .json, .dot or .gv extension, text, json or dot (text by default)")
 	prompt := flag.Bool("prompt", false, "Prompt for input instead")
 	verbose := flag.Bool("verbose", false, "Enable verbose output")
 	summary := flag.Bool("summary", false, "Enable summary output")
+	startClock := flag.String("start", "", "Wall clock time the plan starts at, e.g. 06:00, to print clock times alongside the minute offsets")
+	conflicts := flag.Bool("conflicts", false, "Report trains using a route or a station at the same time beyond its capacity, or in opposite directions on a single track")
+	details := flag.Bool("details", false, "Include the route, departure, arrival and duration of each move in the output")
+	priorityWeight := flag.String("priority-weight", "", "Minutes further away a package of a higher priority class may be and still be picked up first, e.g. 30m (by default higher classes are always picked up first)")
+	gtfsDirectory := flag.String("gtfs", "", "Path to a GTFS static feed directory to import the stations and routes from")
+	var inputFilePaths, overlayFilePaths stringsFlag
+	flag.Var(&inputFilePaths, "i", "Path to the input file, can be specified multiple times to merge e.g. a network file with an orders file")
+	flag.Var(&overlayFilePaths, "overlay", "Path to an overlay file applied on top of the input, can be specified multiple times")
 	flag.Parse()
 
 	var rawInput *RawInput
@@ -170,26 +102,68 @@ func main() {
 		}
 		rawInput = rawInputFromPrompt
 	} else {
-		if *inputFilePath == "" {
-			fmt.Println("Error: Input file path is required, e.g. ./tests/sample.txt")
+		if len(inputFilePaths) == 0 && *gtfsDirectory == "" {
+			fmt.Println("Error: Input file path or GTFS feed directory is required, e.g. ./tests/sample.txt")
 			flag.PrintDefaults()
 			os.Exit(1)
 		}
 
-		rawInputFile, err := ScanInputFile(*inputFilePath)
+		if *gtfsDirectory != "" {
+			gtfsInput, err := ScanGTFSDirectory(*gtfsDirectory)
+			if err != nil {
+				slog.Error(fmt.Sprintf("unable to import GTFS feed: %v", err))
+				os.Exit(1)
+			}
+			rawInput = gtfsInput
+		}
+		for _, inputFilePath := range inputFilePaths {
+			rawInputFile, err := ScanInput(inputFilePath, *format)
+			// the first input is used as it is, so entries repeated within it are left for the validation to report
+			if err == nil && rawInput == nil {
+				rawInput = rawInputFile
+				continue
+			}
+			if err == nil {
+				err = rawInput.Merge(rawInputFile)
+			}
+			if err != nil {
+				slog.Error(fmt.Sprintf("unable to process input fil:e %v", err))
+				os.Exit(1)
+			}
+		}
+	}
+
+	for _, overlayFilePath := range overlayFilePaths {
+		changes, err := ScanOverlayFile(overlayFilePath)
+		if err == nil {
+			err = rawInput.ApplyOverlay(changes)
+		}
 		if err != nil {
-			slog.Error(fmt.Sprintf("unable to process input fil:e %v", err))
+			slog.Error(fmt.Sprintf("unable to apply overlay file: %v", err))
 			os.Exit(1)
 		}
-		rawInput = rawInputFile
 	}
 
-	g, err := graph.NewGraph(rawInput.RawStations, rawInput.RawRoutes, rawInput.RawPackages, rawInput.RawTrains)
+	if diagnostics := rawInput.Validate(); len(diagnostics) > 0 {
+		slog.Error(fmt.Sprintf("found %d problems in the input:\n%s", len(diagnostics), strings.Join(diagnostics, "\n")))
+		os.Exit(1)
+	}
+
+	g, err := graph.NewGraph(rawInput.RawStations, rawInput.RawRoutes, rawInput.RawPackages, rawInput.RawTrains, rawInput.RawIncompatibilities)
 	if err != nil {
 		slog.Error(fmt.Sprintf("There was an issue in building the graph: %v", err))
 		os.Exit(1)
 	}
 
+	if *priorityWeight != "" {
+		weight, err := graph.ParseTravelTime(*priorityWeight)
+		if err != nil || weight < 0 {
+			slog.Error(fmt.Sprintf("invalid priority weight %q, expected a number of minutes such as 30m", *priorityWeight))
+			os.Exit(1)
+		}
+		g.PriorityWeight = weight
+	}
+
 	g.BuildTravelTimeMatrix()
 	if err := g.Deliver(); err != nil {
 		slog.Error(fmt.Sprintf("unable to deliver all packages: %v", err))
@@ -197,6 +171,16 @@ func main() {
 	}
 
 	printer := graph.NewPrinter(g.Moves, g.StationNames, g.TravelTimeMatrix)
+	printer.ShowHopDetails = *details
+	if *startClock != "" {
+		clock, err := graph.ParseClock(*startClock)
+		if err != nil {
+			slog.Error(fmt.Sprintf("invalid start time: %v", err))
+			os.Exit(1)
+		}
+		printer.ShowClock = true
+		printer.StartClock = clock
+	}
 	if *verbose {
 		printer.PrintMovesVerbose()
 	} else {
@@ -206,4 +190,11 @@ func main() {
 	if *summary {
 		printer.PrintSummary()
 	}
+
+	planConflicts := g.Conflicts()
+	if *conflicts {
+		printer.PrintConflicts(planConflicts)
+	} else if len(planConflicts) > 0 {
+		slog.Warn(fmt.Sprintf("the plan has %d conflicts, use --conflicts to list them", len(planConflicts)))
+	}
 }
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// describe formats a source position for error messages, e.g. network.txt:12 or orders.json: $.trains[0]
+func (position SourcePosition) describe() string {
+	switch {
+	case position.Path != "":
+		return fmt.Sprintf("%s: %s", position.File, position.Path)
+	case position.File != "":
+		return fmt.Sprintf("%s:%d", position.File, position.Line)
+	default:
+		return "the prompt"
+	}
+}
+
+// Merge appends the entries of another input, e.g. a network file and an orders file, into this input
+// An entry with the same name as an existing one is reported as a conflict, unless both definitions are identical
+// Entries repeated within the other input are all kept, so the validation reports them with their own positions
+func (input *RawInput) Merge(other *RawInput) error {
+	errs := make([]error, 0)
+	sections := input.sections()
+	for i, otherSection := range other.sections() {
+		section := sections[i]
+		kind := section.kind()
+
+		existing := make(map[string]int, len(*section.Entries))
+		for j, entry := range *section.Entries {
+			existing[section.nameOf(entry)] = j
+		}
+		for j, entry := range *otherSection.Entries {
+			position := SourcePosition{}
+			if j < len(*otherSection.Positions) {
+				position = (*otherSection.Positions)[j]
+			}
+
+			if index, exists := existing[section.nameOf(entry)]; exists {
+				// unnamed entries with the same name are the same entry, e.g. a pair of categories written in the other order
+				if (*section.Entries)[index] == entry || section.Unnamed {
+					continue
+				}
+				existingPosition := SourcePosition{}
+				if index < len(*section.Positions) {
+					existingPosition = (*section.Positions)[index]
+				}
+				errs = append(errs, fmt.Errorf("%s %q is defined differently in %s and %s", kind, entryName(entry), existingPosition.describe(), position.describe()))
+				continue
+			}
+
+			for len(*section.Positions) < len(*section.Entries) {
+				*section.Positions = append(*section.Positions, SourcePosition{})
+			}
+			*section.Entries = append(*section.Entries, entry)
+			*section.Positions = append(*section.Positions, position)
+		}
+	}
+	return errors.Join(errs...)
+}
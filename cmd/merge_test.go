@@ -0,0 +1,141 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// mustParseText parses a text input for a test, failing it if the text cannot be parsed
+func mustParseText(t *testing.T, file string, text string) *RawInput {
+	t.Helper()
+	input, err := ParseInputText(file, text)
+	if err != nil {
+		t.Fatalf("unable to parse %s: %v", file, err)
+	}
+	return input
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		other    string
+		routes   []string
+		incompat []string
+		errors   []string
+	}{
+		{
+			name:   "entries of both inputs are kept",
+			base:   "[routes]\nE1,A,B,10\n",
+			other:  "[routes]\nE2,B,C,20\n",
+			routes: []string{"E1,A,B,10", "E2,B,C,20"},
+		},
+		{
+			name:   "identical definitions are merged",
+			base:   "[routes]\nE1,A,B,10\n",
+			other:  "[routes]\nE1,A,B,10\n",
+			routes: []string{"E1,A,B,10"},
+		},
+		{
+			name:   "different definitions conflict",
+			base:   "[routes]\nE1,A,B,10\n",
+			other:  "[routes]\nE1,A,B,15\n",
+			routes: []string{"E1,A,B,10"},
+			errors: []string{`route "E1" is defined differently in base.txt:2 and other.txt:2`},
+		},
+		{
+			name:   "duplicates within the other input are all kept for the validation",
+			base:   "[routes]\nE1,A,B,10\n",
+			other:  "[routes]\nE2,B,C,20\nE2,B,C,30\n",
+			routes: []string{"E1,A,B,10", "E2,B,C,20", "E2,B,C,30"},
+		},
+		{
+			name:     "a pair of categories in the other order is the same pair",
+			base:     "[incompatibilities]\nhazmat,food\n",
+			other:    "[incompatibilities]\nfood, hazmat\n",
+			incompat: []string{"hazmat,food"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := mustParseText(t, "base.txt", test.base)
+			err := input.Merge(mustParseText(t, "other.txt", test.other))
+
+			if len(test.errors) == 0 && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, expected := range test.errors {
+				if err == nil || !strings.Contains(err.Error(), expected) {
+					t.Errorf("expected an error containing %q, found %v", expected, err)
+				}
+			}
+			if !slices.Equal(input.RawRoutes, test.routes) {
+				t.Errorf("routes: expected %q, found %q", test.routes, input.RawRoutes)
+			}
+			if !slices.Equal(input.RawIncompatibilities, test.incompat) {
+				t.Errorf("incompatibilities: expected %q, found %q", test.incompat, input.RawIncompatibilities)
+			}
+			if len(input.RoutePositions) != len(input.RawRoutes) {
+				t.Errorf("expected a position for each of the %d routes, found %d", len(input.RawRoutes), len(input.RoutePositions))
+			}
+		})
+	}
+}
+
+func TestIncludes(t *testing.T) {
+	directory := t.TempDir()
+	files := map[string]string{
+		"main.txt":            "include network/network.txt\n\n[trains]\nQ1,5,A\n",
+		"network/network.txt": "[stations]\nA\nB\n\n[routes]\nE1,A,B,10\n\ninclude orders.txt\n",
+		"network/orders.txt":  "[packages]\nK1,1,A,B\n",
+		"cycle/a.txt":         "include b.txt\n",
+		"cycle/b.txt":         "include a.txt\n",
+		"conflict/main.txt":   "[routes]\nE1,A,B,10\n\ninclude routes.txt\n",
+		"conflict/routes.txt": "[routes]\nE1,A,B,20\n",
+		"missing/main.txt":    "include nowhere.txt\n",
+	}
+	for name, text := range files {
+		path := filepath.Join(directory, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("nested includes are resolved from the including file", func(t *testing.T) {
+		input, err := ScanInputFile(filepath.Join(directory, "main.txt"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !slices.Equal(input.RawStations, []string{"A", "B"}) || !slices.Equal(input.RawPackages, []string{"K1,1,A,B"}) || !slices.Equal(input.RawTrains, []string{"Q1,5,A"}) {
+			t.Errorf("unexpected input: %+v", input)
+		}
+		if position := input.PackagePositions[0]; position.File != filepath.Join(directory, "network/orders.txt") || position.Line != 2 {
+			t.Errorf("expected the package to be located in network/orders.txt:2, found %s:%d", position.File, position.Line)
+		}
+	})
+
+	errorTests := []struct {
+		name  string
+		file  string
+		error string
+	}{
+		{name: "include cycle", file: "cycle/a.txt", error: "include cycle"},
+		{name: "conflicting included entry", file: "conflict/main.txt", error: `route "E1" is defined differently`},
+		{name: "missing included file", file: "missing/main.txt", error: "unable to include"},
+	}
+	for _, test := range errorTests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ScanInputFile(filepath.Join(directory, test.file))
+			if err == nil || !strings.Contains(err.Error(), test.error) {
+				t.Errorf("expected an error containing %q, found %v", test.error, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Overlay operations, written at the start of each overlay line
+const (
+	OverlayAdd    = '+' // adds a new entry, e.g. + Q3,5,C
+	OverlayRemove = '-' // removes an entry by name, e.g. - E4
+	OverlayModify = '~' // replaces the entry with the same name, e.g. ~ E1,A,B,15
+)
+
+// OverlayChange is a single change of an overlay file applied on top of a base input
+type OverlayChange struct {
+	Operation rune
+	Section   string // stations, routes, packages, trains or incompatibilities
+	Entry     string
+	Position  SourcePosition
+}
+
+// entryName returns the name of a raw entry, which is always its first field
+func entryName(entry string) string {
+	name, _, _ := strings.Cut(entry, ",")
+	return name
+}
+
+func ScanOverlayFile(overlayFilePath string) ([]OverlayChange, error) {
+	file, err := os.ReadFile(overlayFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return ParseOverlay(overlayFilePath, string(file))
+}
+
+/*
+ParseOverlay parses an overlay file, which lists changes to apply on top of a base input under section headers:
+
+	[routes]
+	- E4          // route closed today
+	~ E1,A,B,15   // slower than usual
+	[trains]
+	+ Q3,5,C
+
+Comments and blank lines are ignored the same way as in the text input format
+*/
+func ParseOverlay(overlayFilePath string, text string) ([]OverlayChange, error) {
+	sections := (&RawInput{}).sections()
+	changes := make([]OverlayChange, 0)
+	errs := make([]error, 0)
+	current := -1
+	skipping := false // whether the lines belong to an unknown section, which is only reported once
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	line := 0
+	for scanner.Scan() {
+		line++
+		entry, column := stripComment(scanner.Text())
+		if entry == "" {
+			continue
+		}
+
+		if index, isHeader := parseHeader(entry, sections); isHeader {
+			if index < 0 {
+				errs = append(errs, fmt.Errorf("%s:%d:%d: unknown section %s, expected one of [stations], [routes], [packages], [trains] or [incompatibilities]", overlayFilePath, line, column, entry))
+			}
+			current, skipping = index, index < 0
+			continue
+		}
+		if skipping {
+			continue
+		}
+		if current < 0 {
+			errs = append(errs, fmt.Errorf("%s:%d:%d: overlay changes must follow a section header such as [routes]", overlayFilePath, line, column))
+			continue
+		}
+
+		operation := rune(entry[0])
+		if operation != OverlayAdd && operation != OverlayRemove && operation != OverlayModify {
+			errs = append(errs, fmt.Errorf("%s:%d:%d: overlay change must start with %c (add), %c (remove) or %c (modify)", overlayFilePath, line, column, OverlayAdd, OverlayRemove, OverlayModify))
+			continue
+		}
+		body := strings.TrimLeft(entry[1:], " \t")
+		if body == "" {
+			errs = append(errs, fmt.Errorf("%s:%d:%d: overlay change is missing its entry", overlayFilePath, line, column))
+			continue
+		}
+		// the whole entry identifies an unnamed entry, so there is nothing left to modify
+		if operation == OverlayModify && sections[current].Unnamed {
+			errs = append(errs, fmt.Errorf("%s:%d:%d: %s cannot be modified with %c, remove it with %c and add the new one with %c", overlayFilePath, line, column, sections[current].Name, OverlayModify, OverlayRemove, OverlayAdd))
+			continue
+		}
+		changes = append(changes, OverlayChange{
+			Operation: operation,
+			Section:   sections[current].Name,
+			Entry:     body,
+			Position:  SourcePosition{File: overlayFilePath, Line: line, Column: column + len(entry) - len(body)},
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return changes, nil
+}
+
+// ApplyOverlay applies overlay changes to the input in order
+// Adding an entry whose name already exists, or removing and modifying an entry that does not exist, is reported as an error
+func (input *RawInput) ApplyOverlay(changes []OverlayChange) error {
+	errs := make([]error, 0)
+	for _, change := range changes {
+		var section textSection
+		for _, candidate := range input.sections() {
+			if candidate.Name == change.Section {
+				section = candidate
+			}
+		}
+
+		// prompted input has no positions recorded, keep both slices aligned so entries can be removed by index
+		for len(*section.Positions) < len(*section.Entries) {
+			*section.Positions = append(*section.Positions, SourcePosition{})
+		}
+
+		name := section.nameOf(change.Entry)
+		index := -1
+		for i, existing := range *section.Entries {
+			if section.nameOf(existing) == name {
+				index = i
+				break
+			}
+		}
+		location := fmt.Sprintf("%s:%d:%d", change.Position.File, change.Position.Line, change.Position.Column)
+		kind := section.kind()
+
+		switch change.Operation {
+		case OverlayAdd:
+			if index >= 0 {
+				errs = append(errs, fmt.Errorf("%s: cannot add %s %q, it already exists", location, kind, name))
+				continue
+			}
+			*section.Entries = append(*section.Entries, change.Entry)
+			*section.Positions = append(*section.Positions, change.Position)
+		case OverlayRemove:
+			if index < 0 {
+				errs = append(errs, fmt.Errorf("%s: cannot remove %s %q, it does not exist", location, kind, name))
+				continue
+			}
+			*section.Entries = append((*section.Entries)[:index], (*section.Entries)[index+1:]...)
+			*section.Positions = append((*section.Positions)[:index], (*section.Positions)[index+1:]...)
+		case OverlayModify:
+			if index < 0 {
+				errs = append(errs, fmt.Errorf("%s: cannot modify %s %q, it does not exist", location, kind, name))
+				continue
+			}
+			(*section.Entries)[index] = change.Entry
+			(*section.Positions)[index] = change.Position
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseOverlay(t *testing.T) {
+	changes, err := ParseOverlay("overlay.txt", `# E3 is closed today
+[routes]
+- E3
+~ E4,C,D,25 // slower than usual
+
+[trains]
++ Q3,2,C
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []OverlayChange{
+		{Operation: OverlayRemove, Section: "routes", Entry: "E3", Position: SourcePosition{File: "overlay.txt", Line: 3, Column: 3}},
+		{Operation: OverlayModify, Section: "routes", Entry: "E4,C,D,25", Position: SourcePosition{File: "overlay.txt", Line: 4, Column: 3}},
+		{Operation: OverlayAdd, Section: "trains", Entry: "Q3,2,C", Position: SourcePosition{File: "overlay.txt", Line: 7, Column: 3}},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, found %d: %+v", len(expected), len(changes), changes)
+	}
+	for i, change := range changes {
+		if change.Operation != expected[i].Operation || change.Section != expected[i].Section || change.Entry != expected[i].Entry ||
+			change.Position.Line != expected[i].Position.Line || change.Position.Column != expected[i].Position.Column {
+			t.Errorf("change %d: expected %+v, found %+v", i, expected[i], change)
+		}
+	}
+}
+
+func TestParseOverlayErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		errors []string
+	}{
+		{
+			name:   "change before a section header",
+			text:   "- E3\n",
+			errors: []string{"overlay.txt:1:1: overlay changes must follow a section header such as [routes]"},
+		},
+		{
+			name:   "unknown operation",
+			text:   "[routes]\n* E3\n",
+			errors: []string{"overlay.txt:2:1: overlay change must start with + (add), - (remove) or ~ (modify)"},
+		},
+		{
+			name:   "missing entry",
+			text:   "[routes]\n-\n",
+			errors: []string{"overlay.txt:2:1: overlay change is missing its entry"},
+		},
+		{
+			name:   "unknown section is reported once and its changes skipped",
+			text:   "[depots]\n- D1\n- D2\n",
+			errors: []string{"overlay.txt:1:1: unknown section [depots]"},
+		},
+		{
+			name:   "modifying a pair of categories",
+			text:   "[incompatibilities]\n~ hazmat,food\n",
+			errors: []string{"overlay.txt:2:1: incompatibilities cannot be modified with ~"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseOverlay("overlay.txt", test.text)
+			if err == nil {
+				t.Fatalf("expected errors %q", test.errors)
+			}
+			lines := strings.Split(err.Error(), "\n")
+			if len(lines) != len(test.errors) {
+				t.Fatalf("expected %d errors, found %d: %v", len(test.errors), len(lines), err)
+			}
+			for i, expected := range test.errors {
+				if !strings.Contains(lines[i], expected) {
+					t.Errorf("error %d: expected %q, found %q", i, expected, lines[i])
+				}
+			}
+		})
+	}
+}
+
+func TestApplyOverlay(t *testing.T) {
+	base := `[stations]
+A
+B
+C
+
+[routes]
+E1,A,B,10
+E2,B,C,20
+
+[incompatibilities]
+hazmat,food
+`
+	tests := []struct {
+		name     string
+		overlay  string
+		routes   []string
+		incompat []string
+		errors   []string
+	}{
+		{
+			name:     "add, remove and modify",
+			overlay:  "[routes]\n- E1\n~ E2,B,C,25\n+ E3,A,C,40\n",
+			routes:   []string{"E2,B,C,25", "E3,A,C,40"},
+			incompat: []string{"hazmat,food"},
+		},
+		{
+			name:     "changes are applied in order",
+			overlay:  "[routes]\n- E1\n+ E1,A,B,15\n",
+			routes:   []string{"E2,B,C,20", "E1,A,B,15"},
+			incompat: []string{"hazmat,food"},
+		},
+		{
+			name:     "adding an existing entry",
+			overlay:  "[routes]\n+ E1,A,B,15\n",
+			routes:   []string{"E1,A,B,10", "E2,B,C,20"},
+			incompat: []string{"hazmat,food"},
+			errors:   []string{`overlay.txt:2:3: cannot add route "E1", it already exists`},
+		},
+		{
+			name:     "removing and modifying missing entries",
+			overlay:  "[routes]\n- E9\n~ E8,A,B,5\n",
+			routes:   []string{"E1,A,B,10", "E2,B,C,20"},
+			incompat: []string{"hazmat,food"},
+			errors: []string{
+				`overlay.txt:2:3: cannot remove route "E9", it does not exist`,
+				`overlay.txt:3:3: cannot modify route "E8", it does not exist`,
+			},
+		},
+		{
+			name:     "removing a pair of categories given in the other order",
+			overlay:  "[incompatibilities]\n- food, hazmat\n",
+			routes:   []string{"E1,A,B,10", "E2,B,C,20"},
+			incompat: []string{},
+		},
+		{
+			name:     "adding a pair of categories given in the other order",
+			overlay:  "[incompatibilities]\n+ food,hazmat\n",
+			routes:   []string{"E1,A,B,10", "E2,B,C,20"},
+			incompat: []string{"hazmat,food"},
+			errors:   []string{`overlay.txt:2:3: cannot add incompatibility "food,hazmat", it already exists`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := mustParseText(t, "base.txt", base)
+			changes, err := ParseOverlay("overlay.txt", test.overlay)
+			if err != nil {
+				t.Fatalf("unable to parse the overlay: %v", err)
+			}
+			err = input.ApplyOverlay(changes)
+
+			if len(test.errors) == 0 && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, expected := range test.errors {
+				if err == nil || !strings.Contains(err.Error(), expected) {
+					t.Errorf("expected an error containing %q, found %v", expected, err)
+				}
+			}
+			if !slices.Equal(input.RawRoutes, test.routes) {
+				t.Errorf("routes: expected %q, found %q", test.routes, input.RawRoutes)
+			}
+			if !slices.Equal(input.RawIncompatibilities, test.incompat) {
+				t.Errorf("incompatibilities: expected %q, found %q", test.incompat, input.RawIncompatibilities)
+			}
+			if len(input.RoutePositions) != len(input.RawRoutes) {
+				t.Errorf("expected a position for each of the %d routes, found %d", len(input.RawRoutes), len(input.RoutePositions))
+			}
+		})
+	}
+}
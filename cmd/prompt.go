@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/idea456/development-trains/pkg/graph"
+)
+
+// promptSections maps the text input sections to their graph sections and an example entry shown when prompting
+var promptSections = []struct {
+	Section graph.InputSection
+	Example string
+}{
+	{Section: graph.StationSection, Example: "A"},
+	{Section: graph.RouteSection, Example: "E1,A,B,10"},
+	{Section: graph.PackageSection, Example: "K1,3,A,E"},
+	{Section: graph.TrainSection, Example: "Q1,3,A"},
+	{Section: graph.IncompatibilitySection, Example: "hazmat,food"},
+}
+
+const promptHelp = `Commands:
+	list           show the entries entered so far
+	undo           remove the last entry of the current section
+	delete <name>  remove an entry by name
+	save <path>    save the entries entered so far to a text input file
+	done           finish the current section
+	help           show this message`
+
+func ScanInputFromPrompt() (*RawInput, error) {
+	return RunPrompt(os.Stdin, os.Stdout)
+}
+
+// issuesFor returns the validation issues of the input concerning a single entry
+func (input *RawInput) issuesFor(section graph.InputSection, index int) []graph.InputIssue {
+	issues := make([]graph.InputIssue, 0)
+	for _, issue := range graph.ValidateInput(input.RawStations, input.RawRoutes, input.RawPackages, input.RawTrains, input.RawIncompatibilities) {
+		if issue.Section == section && issue.Index == index {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// removeEntry removes the entry at the given index of a section, along with its position if one was recorded
+func removeEntry(section textSection, index int) string {
+	removed := (*section.Entries)[index]
+	*section.Entries = append((*section.Entries)[:index], (*section.Entries)[index+1:]...)
+	if index < len(*section.Positions) {
+		*section.Positions = append((*section.Positions)[:index], (*section.Positions)[index+1:]...)
+	}
+	return removed
+}
+
+/*
+RunPrompt reads the input interactively section by section, validating every entry as soon as it is entered
+Invalid entries are rejected with the reason so they can be entered again, and commands such as list, undo, delete, save and done manage the entries entered so far
+The prompt ends once the incompatibilities section after the trains is done or the input is closed
+*/
+func RunPrompt(in io.Reader, out io.Writer) (*RawInput, error) {
+	scanner := bufio.NewScanner(in)
+	input := &RawInput{}
+	sections := input.sections()
+
+	fmt.Fprintln(out, "Enter each entry on its own line, type done to finish a section or help to list the commands")
+	current := 0
+	fmt.Fprintf(out, "Enter the %s (one per line, format: %s):\n", sections[current].Name, promptSections[current].Example)
+	fmt.Fprintf(out, "%s> ", sections[current].Name)
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		command, argument, _ := strings.Cut(text, " ")
+		argument = strings.TrimSpace(argument)
+		section := sections[current]
+
+		switch {
+		case text == "":
+		case text == "help":
+			fmt.Fprintln(out, promptHelp)
+		case text == "list":
+			for _, listed := range sections {
+				fmt.Fprintf(out, "%s:\n", listed.Name)
+				for i, entry := range *listed.Entries {
+					fmt.Fprintf(out, "	%d. %s\n", i+1, entry)
+				}
+			}
+		case text == "undo":
+			if len(*section.Entries) == 0 {
+				fmt.Fprintf(out, "There are no %s to undo\n", section.Name)
+				break
+			}
+			fmt.Fprintf(out, "Removed %s\n", removeEntry(section, len(*section.Entries)-1))
+		case (command == "delete" || command == "save") && argument == "":
+			fmt.Fprintf(out, "Usage: %s <%s>\n", command, map[string]string{"delete": "name", "save": "path"}[command])
+		case command == "delete":
+			deleteFromPrompt(input, argument, current, out)
+		case command == "save":
+			if err := input.SaveText(argument); err != nil {
+				fmt.Fprintf(out, "Unable to save the input: %v\n", err)
+				break
+			}
+			fmt.Fprintf(out, "Saved the input to %s\n", argument)
+		case text == "done":
+			current++
+			if current >= len(sections) {
+				return input, nil
+			}
+			fmt.Fprintf(out, "Enter the %s (one per line, format: %s):\n", sections[current].Name, promptSections[current].Example)
+		default:
+			*section.Entries = append(*section.Entries, text)
+			index := len(*section.Entries) - 1
+			if issues := input.issuesFor(promptSections[current].Section, index); len(issues) > 0 {
+				removeEntry(section, index)
+				for _, issue := range issues {
+					fmt.Fprintf(out, "Invalid %s %q: %s, please enter it again\n", promptSections[current].Section, text, issue.Message)
+				}
+			}
+		}
+		fmt.Fprintf(out, "%s> ", sections[current].Name)
+	}
+	fmt.Fprintln(out)
+	return input, scanner.Err()
+}
+
+// deleteFromPrompt deletes an entry by name, looking in the current section first and then in the sections before it
+// The deletion is reverted if it would leave the input invalid, e.g. removing a station that routes still use
+func deleteFromPrompt(input *RawInput, name string, current int, out io.Writer) {
+	sections := input.sections()
+	for i := current; i >= 0; i-- {
+		for j, entry := range *sections[i].Entries {
+			if sections[i].nameOf(entry) != name {
+				continue
+			}
+			removeEntry(sections[i], j)
+			issues := graph.ValidateInput(input.RawStations, input.RawRoutes, input.RawPackages, input.RawTrains, input.RawIncompatibilities)
+			if len(issues) > 0 {
+				*sections[i].Entries = append((*sections[i].Entries)[:j], append([]string{entry}, (*sections[i].Entries)[j:]...)...)
+				fmt.Fprintf(out, "Unable to delete %s %q:\n", promptSections[i].Section, name)
+				for _, issue := range issues {
+					fmt.Fprintf(out, "	%s\n", issue.Error())
+				}
+				return
+			}
+			fmt.Fprintf(out, "Removed %s\n", entry)
+			return
+		}
+	}
+	fmt.Fprintf(out, "There is no entry named %q\n", name)
+}
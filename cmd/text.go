@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// textSection tracks a section of the text input format while it is being parsed
+type textSection struct {
+	Name      string
+	Entries   *[]string
+	Positions *[]SourcePosition
+	Unnamed   bool // entries have no name field, so the whole entry identifies them, e.g. a pair of incompatible categories
+}
+
+// kind returns the singular name of the entries of the section, e.g. route or incompatibility
+func (section textSection) kind() string {
+	if strings.HasSuffix(section.Name, "ies") {
+		return strings.TrimSuffix(section.Name, "ies") + "y"
+	}
+	return strings.TrimSuffix(section.Name, "s")
+}
+
+// nameOf returns the name identifying an entry of the section when merging inputs, applying overlays and deleting entries
+// A pair of unnamed entries is identified by its trimmed fields in sorted order, so hazmat,food and food, hazmat are the same pair
+func (section textSection) nameOf(entry string) string {
+	if !section.Unnamed {
+		return entryName(entry)
+	}
+	fields := strings.Split(entry, ",")
+	for i, field := range fields {
+		fields[i] = strings.TrimSpace(field)
+	}
+	slices.Sort(fields)
+	return strings.Join(fields, ",")
+}
+
+// sections returns the sections of the raw input in the order they appear in headerless input files
+func (input *RawInput) sections() []textSection {
+	return []textSection{
+		{Name: "stations", Entries: &input.RawStations, Positions: &input.StationPositions},
+		{Name: "routes", Entries: &input.RawRoutes, Positions: &input.RoutePositions},
+		{Name: "packages", Entries: &input.RawPackages, Positions: &input.PackagePositions},
+		{Name: "trains", Entries: &input.RawTrains, Positions: &input.TrainPositions},
+		{Name: "incompatibilities", Entries: &input.RawIncompatibilities, Positions: &input.IncompatibilityPositions, Unnamed: true},
+	}
+}
+
+// stripComment removes a trailing # or // comment and surrounding whitespace from a line
+// A comment starts at the beginning of the line or after whitespace, so names and include paths may contain # and //, e.g. K#1
+// Returns the remaining text and the 1-based column it starts at
+func stripComment(line string) (string, int) {
+	for i := 0; i < len(line); i++ {
+		startsComment := line[i] == '#' || strings.HasPrefix(line[i:], "//")
+		if startsComment && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t') {
+			line = line[:i]
+			break
+		}
+	}
+	line = strings.TrimRight(line, " \t\r")
+	trimmed := strings.TrimLeft(line, " \t")
+	return trimmed, len(line) - len(trimmed) + 1
+}
+
+// parseHeader returns the section index of a [section] header line, or -1 if the line is not a header
+func parseHeader(text string, sections []textSection) (int, bool) {
+	if !strings.HasPrefix(text, "[") || !strings.HasSuffix(text, "]") {
+		return -1, false
+	}
+	name := strings.ToLower(strings.TrimSpace(text[1 : len(text)-1]))
+	for i, section := range sections {
+		if name == section.Name || name == section.kind() {
+			return i, true
+		}
+	}
+	return -1, true
+}
+
+// parseCount returns the value of a line holding only a section count
+func parseCount(text string) (int, bool) {
+	count, err := strconv.Atoi(text)
+	if err != nil || count < 0 {
+		return 0, false
+	}
+	return count, true
+}
+
+// IncludeDirective starts a line including another input file, e.g. include network.txt
+const IncludeDirective = "include"
+
+// parseInclude returns the path of an include directive line, relative paths are resolved against the directory of the including file
+func parseInclude(text string, inputFilePath string) (string, bool) {
+	directive, includePath, found := strings.Cut(text, " ")
+	if !found || directive != IncludeDirective {
+		return "", false
+	}
+	includePath = strings.TrimSpace(includePath)
+	if !filepath.IsAbs(includePath) {
+		includePath = filepath.Join(filepath.Dir(inputFilePath), includePath)
+	}
+	return includePath, true
+}
+
+func ScanInputFile(inputFilePath string) (*RawInput, error) {
+	return scanInputFile(inputFilePath, nil)
+}
+
+// scanInputFile reads a text input file, keeping track of the files including it to detect include cycles
+func scanInputFile(inputFilePath string, includedFrom []string) (*RawInput, error) {
+	file, err := os.ReadFile(inputFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return parseInputText(inputFilePath, string(file), includedFrom)
+}
+
+/*
+ParseInputText parses the text input format, which lists the stations, routes, packages, trains and optional incompatibilities sections in this order:
+
+	[stations] // optional header, sections may then appear in any order
+	3          // optional number of entries in the section
+	A
+	B
+	C
+
+Comments starting with # or // at the start of a line or after whitespace and blank lines are ignored anywhere in the file
+Without a header, a section ends once its count is reached, at the next count line, or at the first blank line after its entries if it has no count
+Until its count is reached, a number following the entries of a section without a blank line in between is an entry, e.g. a station named 1
+An "include <path>" line merges the entries of another text, JSON or DOT input file, see RawInput.Merge
+*/
+func ParseInputText(inputFilePath string, text string) (*RawInput, error) {
+	return parseInputText(inputFilePath, text, nil)
+}
+
+func parseInputText(inputFilePath string, text string, includedFrom []string) (*RawInput, error) {
+	includedFrom = append(includedFrom, filepath.Clean(inputFilePath))
+	input := &RawInput{}
+	sections := input.sections()
+
+	current := -1      // index of the section being read
+	expected := -1     // number of entries expected in the current section, -1 if no count was given
+	found := 0         // number of entries read in the current section
+	hasHeader := false // whether the current section was started by a header
+	ended := false     // whether a blank line ended the current headerless section
+	separated := false // whether a blank line came after the previous entry
+	seenHeaders := make(map[int]int, 0)
+
+	// closes the current section, checking that it holds the number of entries it declared
+	finish := func(line int) error {
+		if current >= 0 && expected >= 0 && found != expected {
+			return fmt.Errorf("%s:%d: expected %d %s to be in the input, found %d", inputFilePath, line, expected, sections[current].Name, found)
+		}
+		return nil
+	}
+	// moves on to the section after the current one in headerless input
+	advance := func(line int) error {
+		if err := finish(line); err != nil {
+			return err
+		}
+		if current+1 >= len(sections) {
+			if expected >= 0 {
+				return fmt.Errorf("%s:%d: expected %d %s to be in the input, found more", inputFilePath, line, expected, sections[current].Name)
+			}
+			return fmt.Errorf("%s:%d: unexpected entry after the %s section", inputFilePath, line, sections[len(sections)-1].Name)
+		}
+		current, expected, found, hasHeader, ended = current+1, -1, 0, false, false
+		return nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	line := 0
+	for scanner.Scan() {
+		line++
+		entry, column := stripComment(scanner.Text())
+		if entry == "" {
+			// only truly blank lines separate sections, comment lines do not
+			if strings.TrimSpace(scanner.Text()) == "" {
+				ended = ended || (current >= 0 && !hasHeader && expected < 0 && found > 0)
+				separated = found > 0
+			}
+			continue
+		}
+		wasSeparated := separated
+		separated = false
+
+		if includePath, isInclude := parseInclude(entry, inputFilePath); isInclude {
+			if slices.Contains(includedFrom, filepath.Clean(includePath)) {
+				return nil, fmt.Errorf("%s:%d:%d: include cycle %s -> %s", inputFilePath, line, column, strings.Join(includedFrom, " -> "), includePath)
+			}
+			var included *RawInput
+			var err error
+			if format := extensionFormat(includePath, "text"); format == "text" {
+				included, err = scanInputFile(includePath, includedFrom)
+			} else {
+				included, err = ScanInput(includePath, format)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d:%d: unable to include %s: %w", inputFilePath, line, column, includePath, err)
+			}
+			if err := input.Merge(included); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if index, isHeader := parseHeader(entry, sections); isHeader {
+			if index < 0 {
+				return nil, fmt.Errorf("%s:%d:%d: unknown section %s, expected one of [stations], [routes], [packages], [trains] or [incompatibilities]", inputFilePath, line, column, entry)
+			}
+			if firstLine, exists := seenHeaders[index]; exists {
+				return nil, fmt.Errorf("%s:%d:%d: section %s is already defined on line %d", inputFilePath, line, column, entry, firstLine)
+			}
+			if err := finish(line); err != nil {
+				return nil, err
+			}
+			seenHeaders[index] = line
+			current, expected, found, hasHeader, ended = index, -1, 0, true, false
+			continue
+		}
+
+		// a number right after the entries of a section that still has entries to read is an entry rather than a count, e.g. a station named 1
+		stillCounting := expected >= 0 && found < expected && !wasSeparated
+		if count, isCount := parseCount(entry); isCount && !(hasHeader && (found > 0 || expected >= 0)) && !stillCounting {
+			if hasHeader && found == 0 && expected < 0 {
+				expected = count
+				continue
+			}
+			if err := advance(line); err != nil {
+				return nil, err
+			}
+			expected = count
+			continue
+		}
+
+		if current < 0 || ended || (!hasHeader && expected >= 0 && found == expected) {
+			if err := advance(line); err != nil {
+				return nil, err
+			}
+		}
+		*sections[current].Entries = append(*sections[current].Entries, entry)
+		*sections[current].Positions = append(*sections[current].Positions, SourcePosition{File: inputFilePath, Line: line, Column: column})
+		found++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := finish(line); err != nil {
+		return nil, err
+	}
+
+	return input, nil
+}
+
+// WriteText writes the input in the text input format, with a header and a count for every section
+func (input *RawInput) WriteText(w io.Writer) error {
+	for i, section := range input.sections() {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "[%s]\n%d\n", section.Name, len(*section.Entries)); err != nil {
+			return err
+		}
+		for _, entry := range *section.Entries {
+			if _, err := fmt.Fprintln(w, entry); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SaveText saves the input to a text input file, which can be read again with ScanInputFile
+func (input *RawInput) SaveText(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := input.WriteText(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
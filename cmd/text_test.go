@@ -0,0 +1,195 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStripComment(t *testing.T) {
+	tests := []struct {
+		line   string
+		text   string
+		column int
+	}{
+		{line: "A", text: "A", column: 1},
+		{line: "  E1,A,B,10  ", text: "E1,A,B,10", column: 3},
+		{line: "# a comment", text: "", column: 1},
+		{line: "// a comment", text: "", column: 1},
+		{line: "3 // number of stations", text: "3", column: 1},
+		{line: "E1,A,B,30\t# slow line", text: "E1,A,B,30", column: 1},
+		{line: "K#1,1,A,B", text: "K#1,1,A,B", column: 1},
+		{line: "include orders//today.txt # the orders", text: "include orders//today.txt", column: 1},
+	}
+
+	for _, test := range tests {
+		text, column := stripComment(test.line)
+		if text != test.text || column != test.column {
+			t.Errorf("stripComment(%q): expected %q at column %d, found %q at column %d", test.line, test.text, test.column, text, column)
+		}
+	}
+}
+
+func TestParseInputText(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		stations []string
+		routes   []string
+		packages []string
+		trains   []string
+	}{
+		{
+			name: "counted sections",
+			text: `2 // number of stations
+A
+B
+
+1 // number of routes
+E1,A,B,10
+
+1 // number of packages
+K1,1,A,B
+
+1 // number of trains
+Q1,3,A
+`,
+			stations: []string{"A", "B"},
+			routes:   []string{"E1,A,B,10"},
+			packages: []string{"K1,1,A,B"},
+			trains:   []string{"Q1,3,A"},
+		},
+		{
+			name: "sections ended by blank lines without counts",
+			text: `A
+B
+
+E1,A,B,10
+
+K1,1,A,B
+
+Q1,3,A
+`,
+			stations: []string{"A", "B"},
+			routes:   []string{"E1,A,B,10"},
+			packages: []string{"K1,1,A,B"},
+			trains:   []string{"Q1,3,A"},
+		},
+		{
+			name: "headers in any order with comments in between",
+			text: `# network
+[stations]
+A
+// a comment line does not end the section
+B
+
+[trains]
+Q1,3,A
+
+[packages]
+K#1,1,A,B # names may contain a hash
+
+[routes]
+E1,A,B,10
+`,
+			stations: []string{"A", "B"},
+			routes:   []string{"E1,A,B,10"},
+			packages: []string{"K#1,1,A,B"},
+			trains:   []string{"Q1,3,A"},
+		},
+		{
+			name: "numbers are entries while a counted section is not full",
+			text: `2
+1
+2
+1
+E1,1,2,10
+1
+K1,1,1,2
+1
+Q1,3,1
+`,
+			stations: []string{"1", "2"},
+			routes:   []string{"E1,1,2,10"},
+			packages: []string{"K1,1,1,2"},
+			trains:   []string{"Q1,3,1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input, err := ParseInputText("input.txt", test.text)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, section := range []struct {
+				name     string
+				expected []string
+				found    []string
+			}{
+				{"stations", test.stations, input.RawStations},
+				{"routes", test.routes, input.RawRoutes},
+				{"packages", test.packages, input.RawPackages},
+				{"trains", test.trains, input.RawTrains},
+			} {
+				if !slices.Equal(section.expected, section.found) {
+					t.Errorf("%s: expected %q, found %q", section.name, section.expected, section.found)
+				}
+			}
+		})
+	}
+}
+
+func TestParseInputTextPositions(t *testing.T) {
+	input, err := ParseInputText("input.txt", "[stations]\nA\n  B // indented\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []SourcePosition{{File: "input.txt", Line: 2, Column: 1}, {File: "input.txt", Line: 3, Column: 3}}
+	for i, position := range input.StationPositions {
+		if position.File != expected[i].File || position.Line != expected[i].Line || position.Column != expected[i].Column {
+			t.Errorf("station %d: expected %s:%d:%d, found %s:%d:%d", i, expected[i].File, expected[i].Line, expected[i].Column, position.File, position.Line, position.Column)
+		}
+	}
+}
+
+func TestParseInputTextErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		error string
+	}{
+		{
+			name:  "count mismatch",
+			text:  "3\nA\nB\n\n",
+			error: "input.txt:4: expected 3 stations to be in the input, found 2",
+		},
+		{
+			name:  "unknown section",
+			text:  "[depots]\nD\n",
+			error: "input.txt:1:1: unknown section [depots]",
+		},
+		{
+			name:  "repeated section",
+			text:  "[stations]\nA\n\n[stations]\nB\n",
+			error: "input.txt:4:1: section [stations] is already defined on line 1",
+		},
+		{
+			name:  "entry after the last section",
+			text:  "A\n\nE1,A,A,1\n\nK1,1,A,A\n\nQ1,1,A\n\nx,y\n\nz\n",
+			error: "input.txt:11: unexpected entry after the incompatibilities section",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseInputText("input.txt", test.text)
+			if err == nil {
+				t.Fatalf("expected an error containing %q", test.error)
+			}
+			if !strings.Contains(err.Error(), test.error) {
+				t.Errorf("expected an error containing %q, found %q", test.error, err.Error())
+			}
+		})
+	}
+}
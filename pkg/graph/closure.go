@@ -0,0 +1,194 @@
+package graph
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ClosureSeparator separates the closure windows of a route, e.g. E1,A,B,10,closed=1h-2h;5h-6h
+const ClosureSeparator = ";"
+
+// Closure is a period of time a route cannot be travelled in, e.g. for engineering works
+type Closure struct {
+	From int // time in minutes the route closes at
+	To   int // time in minutes the route reopens at
+}
+
+// overlaps checks if the route is closed at any time during the period from departureTime to arrivalTime
+func (closure Closure) overlaps(departureTime int, arrivalTime int) bool {
+	return closure.From < arrivalTime && departureTime < closure.To
+}
+
+// ParseClosures parses closure windows separated by ClosureSeparator, each a start and end time with an optional unit joined by a dash, e.g. 1h-2h30m
+func ParseClosures(raw string) ([]Closure, error) {
+	closures := make([]Closure, 0)
+	for _, rawClosure := range strings.Split(raw, ClosureSeparator) {
+		rawFrom, rawTo, found := strings.Cut(strings.TrimSpace(rawClosure), "-")
+		if !found {
+			return nil, fmt.Errorf("%q is not a valid closure window, e.g. 1h-2h", rawClosure)
+		}
+		from, err := ParseTravelTime(rawFrom)
+		if err != nil {
+			return nil, err
+		}
+		to, err := ParseTravelTime(rawTo)
+		if err != nil {
+			return nil, err
+		}
+		if to <= from {
+			return nil, fmt.Errorf("%q must reopen after it closes", rawClosure)
+		}
+		closures = append(closures, Closure{From: from, To: to})
+	}
+	return closures, nil
+}
+
+// isOpen checks if the route is not closed at any time during the period from departureTime to arrivalTime
+func (route *Route) isOpen(departureTime int, arrivalTime int) bool {
+	for _, closure := range route.Closures {
+		if closure.overlaps(departureTime, arrivalTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// nextOpening returns the earliest time from the given time a train taking the given travel time can travel the route without running into a closure
+func (route *Route) nextOpening(time int, travelTime int) int {
+	for departureTime := time; ; {
+		closed := false
+		for _, closure := range route.Closures {
+			if closure.overlaps(departureTime, departureTime+travelTime) {
+				departureTime = closure.To
+				closed = true
+			}
+		}
+		if !closed {
+			return departureTime
+		}
+	}
+}
+
+// hasClosures checks if any route is closed at some point, the travel matrices only hold for networks without closures
+func (g *Graph) hasClosures() bool {
+	for _, adjacentRoutes := range g.Routes {
+		for _, routes := range adjacentRoutes {
+			for _, route := range routes {
+				if len(route.Closures) > 0 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+/*
+earliestArrivalPath finds the path between 2 stations a train with the given speed factor and load setting off at the given time arrives earliest on
+It is a variant of loadLimitedPath where the train waits at a station for a closed route to reopen when that is quicker than travelling around it
+Returns the path and the time it arrives at, or nil and MaxInt if the ending station cannot be reached with the load
+Time complexity: O(V^2 + E*C) where V is the number of stations, E the number of routes and C the number of closures of a route
+*/
+func (g *Graph) earliestArrivalPath(speed float64, load int, departureTime int, startingStationId StationId, endingStationId StationId) ([]StationId, int) {
+	arrivalTimes := make(map[StationId]int, len(g.Stations))
+	previousStations := make(map[StationId]StationId, len(g.Stations))
+	visited := make(map[StationId]bool, len(g.Stations))
+	for stationId := range g.Stations {
+		arrivalTimes[stationId] = MaxInt
+	}
+	arrivalTimes[startingStationId] = departureTime
+
+	stationIds := make([]StationId, 0, len(g.Stations))
+	for stationId := range g.Stations {
+		stationIds = append(stationIds, stationId)
+	}
+	slices.Sort(stationIds)
+
+	for {
+		// visit the station reached earliest not visited yet, the lowest id first to keep the paths stable
+		currentStationId := -1
+		for _, stationId := range stationIds {
+			if !visited[stationId] && arrivalTimes[stationId] < MaxInt && (currentStationId < 0 || arrivalTimes[stationId] < arrivalTimes[currentStationId]) {
+				currentStationId = stationId
+			}
+		}
+		if currentStationId < 0 || currentStationId == endingStationId {
+			break
+		}
+		visited[currentStationId] = true
+
+		for adjacentStationId, routes := range g.Routes[currentStationId] {
+			for _, route := range routes {
+				if !route.AllowsLoad(load) {
+					continue
+				}
+				travelTime := route.TravelTimeAt(speed)
+				if arrivalTime := route.nextOpening(arrivalTimes[currentStationId], travelTime) + travelTime; arrivalTime < arrivalTimes[adjacentStationId] {
+					arrivalTimes[adjacentStationId] = arrivalTime
+					previousStations[adjacentStationId] = currentStationId
+				}
+			}
+		}
+	}
+
+	if arrivalTimes[endingStationId] == MaxInt {
+		return nil, MaxInt
+	}
+	paths := []StationId{endingStationId}
+	for stationId := endingStationId; stationId != startingStationId; {
+		stationId = previousStations[stationId]
+		paths = append(paths, stationId)
+	}
+	slices.Reverse(paths)
+	return paths, arrivalTimes[endingStationId]
+}
+
+// closedRouteOn follows a path from the current time of a train, returning the time it arrives at and the first route it has to wait on to reopen, if any
+func (g *Graph) closedRouteOn(train *Train, paths []StationId) (int, string) {
+	time, closedRoute := train.TravelTime, ""
+	for i := 0; i < len(paths)-1; i++ {
+		var fastestRoute *Route
+		arrivalTime := MaxInt
+		for _, route := range g.Routes[paths[i]][paths[i+1]] {
+			if !route.AllowsLoad(train.Load()) {
+				continue
+			}
+			travelTime := route.TravelTimeAt(train.Speed)
+			if routeArrivalTime := route.nextOpening(time, travelTime) + travelTime; routeArrivalTime < arrivalTime {
+				fastestRoute = route
+				arrivalTime = routeArrivalTime
+			}
+		}
+		if fastestRoute == nil {
+			return MaxInt, closedRoute
+		}
+		if closedRoute == "" && arrivalTime > time+fastestRoute.TravelTimeAt(train.Speed) {
+			closedRoute = fastestRoute.Name
+		}
+		time = arrivalTime
+	}
+	return time, closedRoute
+}
+
+/*
+plannedPath returns the path a train takes between 2 stations when it sets off now
+Without closures it is the fastest path of shortestPathFor, otherwise the train takes a detour if a route on it is closed and going around it gets there sooner than waiting
+Returns the path and the closed route the train travels around, empty if it takes the usual path
+*/
+func (g *Graph) plannedPath(trainName string, startingStationId StationId, endingStationId StationId) ([]StationId, string) {
+	paths := g.shortestPathFor(trainName, startingStationId, endingStationId)
+	if !g.hasClosures() || paths == nil {
+		return paths, ""
+	}
+	train := g.Trains[trainName]
+	usualArrivalTime, closedRoute := g.closedRouteOn(train, paths)
+	if closedRoute == "" {
+		return paths, ""
+	}
+	detourPaths, detourArrivalTime := g.earliestArrivalPath(train.Speed, train.Load(), train.TravelTime, startingStationId, endingStationId)
+	if detourPaths == nil || detourArrivalTime >= usualArrivalTime || slices.Equal(detourPaths, paths) {
+		return paths, ""
+	}
+	return detourPaths, closedRoute
+}
@@ -0,0 +1,19 @@
+package graph
+
+// Compatible checks if 2 packages can be carried by a train at the same time, packages without a category go with any package
+func (g *Graph) Compatible(packageX Package, packageY Package) bool {
+	if packageX.Category == "" || packageY.Category == "" {
+		return true
+	}
+	return !g.Incompatible[packageX.Category][packageY.Category]
+}
+
+// canCombine checks if a package can be loaded onto a train along with every package the train is already carrying
+func (g *Graph) canCombine(train *Train, delivery Package) bool {
+	for _, carriedPackage := range train.PackagesCarried {
+		if !g.Compatible(carriedPackage, delivery) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package graph
+
+import (
+	"fmt"
+	"slices"
+)
+
+// NoDepot is the depot of trains that stay wherever they drop off their last package
+const NoDepot StationId = -1
+
+// returnTime returns how long a train would take to get back to its depot after delivering a package, 0 if it has no depot
+func (g *Graph) returnTime(train *Train, delivery Package) int {
+	if !train.HasDepot() {
+		return 0
+	}
+	return g.travelTime(train.Name, delivery.EndingStationId, train.DepotStationId)
+}
+
+/*
+returnToDepots moves every train with a depot back to it once all packages are delivered
+Like the drop offs, a train whose way back is blocked by a full station lets the other trains move first
+and if none of them can move either they are waiting on each other's platforms
+*/
+func (g *Graph) returnToDepots() error {
+	returningTrains := make([]string, 0)
+	for trainName, train := range g.Trains {
+		if train.HasDepot() && train.CurrentStationId != train.DepotStationId {
+			returningTrains = append(returningTrains, trainName)
+		}
+	}
+	slices.Sort(returningTrains)
+
+	blocks := make(map[string]platformBlock, 0)
+	deferredTrains := 0
+	for len(returningTrains) > 0 {
+		trainName := returningTrains[0]
+		returningTrains = returningTrains[1:]
+		train := g.Trains[trainName]
+
+		if !g.IsReachable(train.CurrentStationId, train.DepotStationId) {
+			return fmt.Errorf("train %s cannot return to its depot at station %s from station %s", trainName, g.StationNames[train.DepotStationId], g.StationNames[train.CurrentStationId])
+		}
+		if block, blocked := g.blockedStation(trainName, train.DepotStationId); blocked {
+			blocks[trainName] = block
+			deferredTrains++
+			if deferredTrains > len(returningTrains) {
+				return g.deadlockError(blocks)
+			}
+			returningTrains = append(returningTrains, trainName)
+			continue
+		}
+		delete(blocks, trainName)
+		deferredTrains = 0
+		g.MoveToDepot(trainName)
+		// NOTE: the pickups leave room in the shift for the way back, but the work they take is only estimated
+		if train.ShiftLimit > 0 && train.DutyTime > train.ShiftLimit {
+			return fmt.Errorf("the crew of train %s would work %d minutes returning to its depot at station %s, past the end of its %d minute shift", trainName, train.DutyTime, g.StationNames[train.DepotStationId], train.ShiftLimit)
+		}
+	}
+	return nil
+}
+
+// MoveToDepot moves a train along its shortest path back to its depot, marking the moves as its return leg
+func (g *Graph) MoveToDepot(trainName string) {
+	train := g.Trains[trainName]
+	paths, detourAround := g.plannedPath(trainName, train.CurrentStationId, train.DepotStationId)
+	for i := 0; i < len(paths)-1; i++ {
+		firstMove := len(g.Moves)
+		move := g.travelRoute(trainName, paths[i], paths[i+1])
+		move.DetourAround = detourAround
+		g.Moves = append(g.Moves, move)
+		// travelRoute may add a wait before the move, which is part of the return leg as well
+		for j := firstMove; j < len(g.Moves); j++ {
+			g.Moves[j].ReturnLeg = true
+		}
+	}
+}
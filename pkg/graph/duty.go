@@ -0,0 +1,78 @@
+package graph
+
+import (
+	"fmt"
+	"slices"
+)
+
+// DefaultRestTime is how many minutes a rest break lasts for crews with a duty limit but no rest time
+const DefaultRestTime = 45
+
+// work adds the minutes the crew spends travelling or handling packages to its duty time
+func (train *Train) work(minutes int) {
+	train.DutyTime += minutes
+	train.ContinuousDuty += minutes
+}
+
+/*
+restIfDue makes the crew of a train take a rest break at its station if the upcoming work would take it past its duty limit
+The break is added as a rest move, a wait at least as long as a break already counts as one, see Train.WaitUntil
+Work longer than the duty limit on its own, e.g. a very long route, is started straight after a break
+*/
+func (g *Graph) restIfDue(trainName string, minutes int) {
+	train := g.Trains[trainName]
+	if train.DutyLimit == 0 || train.ContinuousDuty == 0 || train.ContinuousDuty+minutes <= train.DutyLimit {
+		return
+	}
+	g.Moves = append(g.Moves, Move{
+		Kind:            RestMove,
+		TimeTaken:       train.TravelTime,
+		Train:           *train,
+		DepartureTime:   train.TravelTime,
+		ArrivalTime:     train.TravelTime + train.RestTime,
+		Duration:        train.RestTime,
+		StartingStation: *g.Stations[train.CurrentStationId],
+		EndingStation:   *g.Stations[train.CurrentStationId],
+		PackagesCarried: train.PackagesCarried,
+		Reason:          fmt.Sprintf("after %d minutes on duty", train.ContinuousDuty),
+	})
+	train.TravelTime += train.RestTime
+	train.ContinuousDuty = 0
+}
+
+/*
+estimatedDuty returns roughly how many minutes of work picking up a package adds to the shift of a train
+The train is assumed to drop off the new package and the ones it already carries at the nearest of their destinations first, like the drop offs do
+It includes the way back to the depot of the train, if it has one, since the crew has to take the train there before the shift ends
+Waits and rest breaks are not work, so they are not counted
+*/
+func (g *Graph) estimatedDuty(train *Train, delivery Package) int {
+	packagesByDestination := make(map[StationId][]Package, 0)
+	for _, carriedPackage := range append(slices.Clone(train.PackagesCarried), delivery) {
+		packagesByDestination[carriedPackage.EndingStationId] = append(packagesByDestination[carriedPackage.EndingStationId], carriedPackage)
+	}
+
+	duty := g.travelTime(train.Name, train.CurrentStationId, delivery.StartingStationId) + g.Stations[delivery.StartingStationId].HandlingTimeFor([]Package{delivery}) + train.CouplingTime
+	currentStationId := delivery.StartingStationId
+	for len(packagesByDestination) > 0 {
+		nextStationId, nextTravelTime := -1, MaxInt
+		for destinationStationId := range packagesByDestination {
+			travelTime := g.travelTime(train.Name, currentStationId, destinationStationId)
+			if nextStationId < 0 || travelTime < nextTravelTime || (travelTime == nextTravelTime && destinationStationId < nextStationId) {
+				nextStationId, nextTravelTime = destinationStationId, travelTime
+			}
+		}
+		duty += nextTravelTime + g.Stations[nextStationId].HandlingTimeFor(packagesByDestination[nextStationId]) + train.CouplingTime
+		delete(packagesByDestination, nextStationId)
+		currentStationId = nextStationId
+	}
+	if train.HasDepot() {
+		duty += g.travelTime(train.Name, currentStationId, train.DepotStationId)
+	}
+	return duty
+}
+
+// withinShift checks if a train's crew can pick up and deliver a package without working past its shift limit
+func (g *Graph) withinShift(train *Train, delivery Package) bool {
+	return train.ShiftLimit == 0 || train.DutyTime+g.estimatedDuty(train, delivery) <= train.ShiftLimit
+}
@@ -3,8 +3,8 @@ package graph
 import (
 	"container/heap"
 	"fmt"
+	"math"
 	"slices"
-	"strconv"
 	"strings"
 )
 
@@ -15,6 +15,12 @@ type PackageName = string
 // temporary use a large number to represent integer infinity
 const MaxInt = 9999999999999
 
+// Direction markers accepted as the optional fifth field of a route, e.g. E1,A,B,10,->
+const (
+	OneWayMarker = "->"  // the route can only be travelled from its first station to its second station
+	TwoWayMarker = "<->" // the route can be travelled in both directions, which is the default
+)
+
 // Package struct represents the package to be delivered
 type Package struct {
 	Name              PackageName
@@ -22,6 +28,24 @@ type Package struct {
 	StartingStationId StationId
 	EndingStationId   StationId
 	DeliveredAt       int
+	ReadyTime         int // time in minutes the package can be picked up from
+	DueTime           int // time in minutes the package should be delivered by, 0 if it has no deadline
+	Priority          PriorityClass
+	Volume            int    // volume in litres the package takes up in a train, 0 if it is not known
+	Category          string // category deciding which packages it can be carried with, empty if it has none
+}
+
+// HasDeadline checks if the package has to be delivered by its due time
+func (delivery Package) HasDeadline() bool {
+	return delivery.DueTime > 0
+}
+
+// Lateness returns how many minutes after its due time a package delivered at the given time arrives, 0 if it is on time
+func (delivery Package) Lateness(deliveredAt int) int {
+	if !delivery.HasDeadline() {
+		return 0
+	}
+	return max(deliveredAt-delivery.DueTime, 0)
 }
 
 // Station represents a station node
@@ -29,142 +53,121 @@ type Station struct {
 	Id              StationId
 	Name            string
 	InitialPackages map[PackageName]*Package
+	HandlingTime    int     // minutes taken to load or unload packages at the station, regardless of their weight
+	HandlingRate    float64 // additional minutes taken per kilogram of packages loaded or unloaded
+	Platforms       int     // number of trains the station can hold at once, 0 if unlimited
+}
+
+// HandlingTimeFor returns the whole minutes taken to load or unload the given packages at the station
+func (station Station) HandlingTimeFor(packages []Package) int {
+	if len(packages) == 0 {
+		return 0
+	}
+	totalWeight := 0
+	for _, delivery := range packages {
+		totalWeight += delivery.Weight
+	}
+	return station.HandlingTime + int(math.Ceil(station.HandlingRate*float64(totalWeight)))
 }
 
 // Route represents the weighted edges between stations with travel time
 type Route struct {
-	Name       string
-	TravelTime int
+	Name        string
+	TravelTime  int
+	OneWay      bool
+	Capacity    int       // number of trains allowed on the route at the same time in each direction, 0 if unlimited
+	SingleTrack bool      // trains cannot travel the route in opposite directions at the same time
+	MaxSpeed    float64   // highest speed factor trains can travel the route at, 0 if unlimited
+	MaxLoad     int       // heaviest load in kilograms a train may carry on the route, 0 if unlimited
+	Closures    []Closure // periods the route cannot be travelled in
 }
 
+// MoveKind tells apart the moves travelling between stations from the periods a train spends at a station
+type MoveKind int
+
+const (
+	TravelMove MoveKind = iota // the train travels a route, or stays at the station to pick up or drop off packages
+	DwellMove                  // the train is held at the station while packages are loaded and unloaded
+	WaitMove                   // the train waits at the station, e.g. for a package to be ready
+	RestMove                   // the crew of the train takes a rest break at the station
+)
+
 // Move represents a train's movement and pickup/dropoff actions
+// Times are in minutes since the start of the plan
 type Move struct {
-	TimeTaken       int
+	Kind            MoveKind
+	TimeTaken       int // time the train starts the move at, same as DepartureTime
 	Train           Train
+	RouteName       string // name of the route taken between the stations, empty if the train stays at the station
+	DepartureTime   int
+	ArrivalTime     int
+	Duration        int // time taken to travel the route, 0 if the train stays at the station
 	StartingStation Station
 	EndingStation   Station
 	PackagesCarried []Package
 	PackagesDropped []Package
+	Reason          string // what the train is dwelling or waiting for, empty for travel moves
+	ReturnLeg       bool   // the train is travelling back to its depot after every package is delivered
+	DetourAround    string // closed route the train takes a detour around, empty if it takes its usual path
 }
 
 // Graph represents the transit network
 // Edges are represented with a 'hashmap' adjancency matrix to optimise space for non-existing edges
+// There can be multiple parallel routes between the same pair of stations, e.g. an express and a local line
 type Graph struct {
 	Stations         map[StationId]*Station
 	StationNames     map[StationId]StationName
-	Routes           map[StationId]map[StationId]*Route
+	Routes           map[StationId]map[StationId][]*Route
 	Deliveries       []Package
 	Trains           map[string]*Train
 	TravelTimeMatrix map[StationId]map[StationId]int       // Stores shortest travel time between all stations
 	TravelPathMatrix map[StationId]map[StationId]StationId // Stores references of previous nodes to backtrack shortest path
 	Moves            []Move                                // Tracks list of moves performed by the trains
-}
 
-// Creates a new Graph instance, receives the raw input strings of the stations, routes, deliveries and trains
-func NewGraph(stationNames []StationName, rawRoutes []string, rawDeliveries []string, rawTrains []string) (*Graph, error) {
-	stations := make(map[int]*Station, 0)
-	stationNamesToIdMap := make(map[string]int, 0)
-	stationNamesMap := make(map[int]string, 0)
-	for i, stationName := range stationNames {
-		stations[i] = &Station{
-			Id:              i,
-			Name:            stationName,
-			InitialPackages: make(map[PackageName]*Package, 0),
-		}
-		stationNamesToIdMap[stationName] = i
-		stationNamesMap[i] = stationName
-	}
-
-	routes := make(map[int]map[int]*Route, 0)
-	for _, rawRoute := range rawRoutes {
-		route := strings.Split(rawRoute, ",")
-		routeName := route[0]
-		fromStation := stationNamesToIdMap[route[1]]
-		toStation := stationNamesToIdMap[route[2]]
-		travelTime, err := strconv.Atoi(route[3])
-		if err != nil {
-			return nil, fmt.Errorf("Route %s is not in integer format", routeName)
-		}
-
-		if _, exists := routes[fromStation]; !exists {
-			routes[fromStation] = make(map[int]*Route, 0)
-		}
-		if _, exists := routes[toStation]; !exists {
-			routes[toStation] = make(map[int]*Route, 0)
-		}
-
-		// bidirectional
-		routes[fromStation][toStation] = &Route{
-			Name:       routeName,
-			TravelTime: travelTime,
-		}
-		routes[toStation][fromStation] = &Route{
-			Name:       routeName,
-			TravelTime: travelTime,
-		}
-	}
-
-	deliveries := make([]Package, 0)
-	for _, rawDelivery := range rawDeliveries {
-		delivery := strings.Split(rawDelivery, ",")
-		packageName := delivery[0]
-		weight, err := strconv.Atoi(delivery[1])
-		if err != nil {
-			return nil, fmt.Errorf("Package %s weight is not in integer format", packageName)
-		}
-		fromStationName := delivery[2]
-		toStationName := delivery[3]
+	// PriorityWeight is how many minutes further away a package of a higher priority class may be and still be picked up first
+	// StrictPriority always picks up packages of a higher class first, 0 ignores the priority classes
+	PriorityWeight int
 
-		fromStationId := stationNamesToIdMap[fromStationName]
-		toStationId := stationNamesToIdMap[toStationName]
+	// Incompatible holds the pairs of package categories that must not be carried by a train at the same time, in both orders
+	Incompatible map[string]map[string]bool
 
-		newDelivery := Package{
-			Name:              packageName,
-			Weight:            weight,
-			StartingStationId: fromStationId,
-			EndingStationId:   toStationId,
-		}
-
-		// keep track of which stations is initially holding the packages
-		stations[fromStationId].InitialPackages[newDelivery.Name] = &newDelivery
-
-		deliveries = append(deliveries, newDelivery)
-	}
-
-	trains := make(map[string]*Train, 0)
-	for _, rawTrain := range rawTrains {
-		train := strings.Split(rawTrain, ",")
-		trainName := train[0]
-		capacity, err := strconv.Atoi(train[1])
-		if err != nil {
-			return nil, fmt.Errorf("Train %s capacity is not an integer", trainName)
-		}
-		startingStationName := train[2]
-
-		startingStationId := stationNamesToIdMap[startingStationName]
-
-		trains[trainName] = &Train{
-			Name:             trainName,
-			Capacity:         capacity,
-			CurrentStationId: startingStationId,
-			PackagesCarried:  make([]Package, 0),
-		}
+	occupations        map[string][]routeOccupation      // periods each route is travelled by the trains, by route name
+	stationOccupations map[StationId][]stationOccupation // periods each station holds the trains
+	speedMatrices      map[float64]travelMatrices        // shortest travel times and paths for each speed factor of the trains
+	lowestMaxLoad      int                               // smallest load limit of the routes, 0 if no route limits the load
+}
 
+// Creates a new Graph instance, receives the raw input strings of the stations, routes, deliveries, trains and incompatible package categories
+// The strings are parsed into a Problem, see NewGraphFromProblem, and every issue found is returned as a joined error
+func NewGraph(rawStations []string, rawRoutes []string, rawDeliveries []string, rawTrains []string, rawIncompatibilities []string) (*Graph, error) {
+	problem, issues := ParseProblem(rawStations, rawRoutes, rawDeliveries, rawTrains, rawIncompatibilities)
+	if len(issues) > 0 {
+		return nil, joinIssues(issues)
 	}
-
-	return &Graph{
-		Stations:     stations,
-		StationNames: stationNamesMap,
-		Routes:       routes,
-		Deliveries:   deliveries,
-		Trains:       trains,
-		Moves:        make([]Move, 0),
-	}, nil
+	return NewGraphFromProblem(problem)
 }
 
 // BuildTravelTimeMatrix creates a distance matrix for every shortest path between every stations using Floyd-Warshall algorithm
 // Allows for O(1) lookup for every shortest-path between stations, but costs O(V^3) preprocessing time
+// The matrices are built for the normal speed, and once more for each speed factor of the trains
 func (g *Graph) BuildTravelTimeMatrix() {
+	matrices := g.buildTravelMatrices(NormalSpeed)
+	g.TravelTimeMatrix = matrices.TravelTime
+	g.TravelPathMatrix = matrices.TravelPath
+
+	// the fastest paths differ per speed class once routes limit the speed of the faster trains
+	g.speedMatrices = make(map[float64]travelMatrices, 0)
+	for _, speed := range g.trainSpeeds() {
+		if speed == NormalSpeed {
+			g.speedMatrices[speed] = matrices
+			continue
+		}
+		g.speedMatrices[speed] = g.buildTravelMatrices(speed)
+	}
+}
+
+// buildTravelMatrices runs Floyd-Warshall with the travel times of the routes for a train with the given speed factor
+func (g *Graph) buildTravelMatrices(speed float64) travelMatrices {
 	// Run Floyd-Warshall to get all-pairs shortest path first for all stations
 	travelTimeMatrix := make(map[StationId]map[StationId]int, 0)
 	// Store references of the previous paths to backtrack and reconstruct the shortest path
@@ -181,15 +184,11 @@ func (g *Graph) BuildTravelTimeMatrix() {
 		travelPathMatrix[stationId] = make(map[StationId]StationId, 0)
 
 		// Initialise base cases, allocate memory and initial travel times if connection exists
+		// Routes may be one-way, so only the stationId -> adjacentStationId direction is filled in here
 		for adjacentStationId := range stationIds {
-			if existingRoute, exists := g.Routes[stationId][adjacentStationId]; exists {
-				travelTimeMatrix[stationId][adjacentStationId] = existingRoute.TravelTime
-
+			if existingRoute := g.fastestRouteAt(stationId, adjacentStationId, speed); existingRoute != nil {
+				travelTimeMatrix[stationId][adjacentStationId] = existingRoute.TravelTimeAt(speed)
 				travelPathMatrix[stationId][adjacentStationId] = stationId
-				if _, exists := travelPathMatrix[adjacentStationId]; !exists {
-					travelPathMatrix[adjacentStationId] = make(map[StationId]StationId, 0)
-				}
-				travelPathMatrix[adjacentStationId][stationId] = adjacentStationId
 			} else {
 				travelTimeMatrix[stationId][adjacentStationId] = MaxInt
 			}
@@ -203,25 +202,41 @@ func (g *Graph) BuildTravelTimeMatrix() {
 		for iStation := range stationIds {
 			for jStation := range stationIds {
 				if travelTimeMatrix[iStation][jStation] > travelTimeMatrix[iStation][kStation]+travelTimeMatrix[kStation][jStation] {
+					// NOTE: the [j][i] entry is not updated symmetrically since travel times can differ per direction with one-way routes
 					travelTimeMatrix[iStation][jStation] = travelTimeMatrix[iStation][kStation] + travelTimeMatrix[kStation][jStation]
-					travelTimeMatrix[jStation][iStation] = travelTimeMatrix[jStation][kStation] + travelTimeMatrix[kStation][iStation]
 
 					// https://en.wikipedia.org/wiki/Floyd%E2%80%93Warshall_algorithm#Path_reconstruction
 					travelPathMatrix[iStation][jStation] = travelPathMatrix[kStation][jStation]
-					travelPathMatrix[jStation][iStation] = travelPathMatrix[kStation][iStation]
-
 				}
 			}
 		}
 	}
 
-	g.TravelTimeMatrix = travelTimeMatrix
-	g.TravelPathMatrix = travelPathMatrix
+	return travelMatrices{TravelTime: travelTimeMatrix, TravelPath: travelPathMatrix}
+}
+
+// GetRoute returns the fastest of the routes connecting 2 adjacent stations in the given direction, or nil if they are not connected
+// If parallel routes have the same travel time, the one defined first is chosen
+func (g *Graph) GetRoute(startingStationId StationId, endingStationId StationId) *Route {
+	return g.fastestRouteAt(startingStationId, endingStationId, NormalSpeed)
 }
 
-// GetShortestPath returns the backtracked shortest path between 2 stations
+// IsReachable checks if there is a path from the starting station to the ending station, which might not be the case with one-way routes
+func (g *Graph) IsReachable(startingStationId StationId, endingStationId StationId) bool {
+	return g.TravelTimeMatrix[startingStationId][endingStationId] < MaxInt
+}
+
+// GetShortestPath returns the backtracked shortest path between 2 stations at the normal speed, or nil if the ending station cannot be reached
 // Time complexity: O(E) where E is the number of routes
 func (g *Graph) GetShortestPath(startingStationId StationId, endingStationId StationId) []StationId {
+	return backtrackPath(travelMatrices{TravelTime: g.TravelTimeMatrix, TravelPath: g.TravelPathMatrix}, startingStationId, endingStationId)
+}
+
+// backtrackPath reconstructs the shortest path between 2 stations from the travel matrices, or nil if the ending station cannot be reached
+func backtrackPath(matrices travelMatrices, startingStationId StationId, endingStationId StationId) []StationId {
+	if matrices.TravelTime[startingStationId][endingStationId] >= MaxInt {
+		return nil
+	}
 	paths := make([]StationId, 0)
 	start := startingStationId
 	end := endingStationId
@@ -230,7 +245,7 @@ func (g *Graph) GetShortestPath(startingStationId StationId, endingStationId Sta
 	// https://en.wikipedia.org/wiki/Floyd%E2%80%93Warshall_algorithm#Path_reconstruction
 	paths = append(paths, end)
 	for start != end {
-		end = g.TravelPathMatrix[start][end]
+		end = matrices.TravelPath[start][end]
 
 		paths = append(paths, end)
 	}
@@ -238,52 +253,204 @@ func (g *Graph) GetShortestPath(startingStationId StationId, endingStationId Sta
 	return paths
 }
 
+/*
+travelRoute moves a train to an adjacent station, and returns the move to track
+Of the parallel routes between the stations allowing the load of the train, the one it arrives the earliest with at its speed is chosen
+If the route is still taken by other trains, or there is no free platform at the next station when the train would arrive,
+the train waits at the station until it can go, which is tracked as a move
+*/
+func (g *Graph) travelRoute(trainName string, startingStationId StationId, endingStationId StationId) Move {
+	train := g.Trains[trainName]
+	parallelRoutes := g.routesAllowing(train.Load(), startingStationId, endingStationId)
+	// the crew rests before setting off if even the quickest of the parallel routes would take it past its duty limit
+	quickestTravelTime := MaxInt
+	for _, parallelRoute := range parallelRoutes {
+		quickestTravelTime = min(quickestTravelTime, parallelRoute.TravelTimeAt(train.Speed))
+	}
+	g.restIfDue(trainName, quickestTravelTime)
+
+	var route *Route
+	departureTime, travelTime := train.TravelTime, 0
+	waitsForRoute, waitsForClosure, waitsForPlatform := false, false, false
+	for {
+		earliestDeparture := departureTime
+		route = nil
+		for _, parallelRoute := range parallelRoutes {
+			parallelDeparture := g.earliestDeparture(parallelRoute, startingStationId, departureTime, parallelRoute.TravelTimeAt(train.Speed))
+			if route == nil || parallelDeparture+parallelRoute.TravelTimeAt(train.Speed) < earliestDeparture+route.TravelTimeAt(train.Speed) {
+				route = parallelRoute
+				earliestDeparture = parallelDeparture
+			}
+		}
+		travelTime = route.TravelTimeAt(train.Speed)
+		waitsForRoute = waitsForRoute || earliestDeparture > departureTime
+		waitsForClosure = waitsForClosure || !route.isOpen(departureTime, departureTime+travelTime)
+		departureTime = earliestDeparture
+
+		// NOTE: the station might be full with trains that have not departed yet, Deliver checks for it before moving the train
+		arrivalTime := g.earliestArrival(endingStationId, train.Name, departureTime+travelTime)
+		if arrivalTime == departureTime+travelTime || arrivalTime == MaxInt {
+			break
+		}
+		waitsForPlatform = true
+		departureTime = arrivalTime - travelTime
+	}
+
+	if departureTime > train.TravelTime {
+		waitingFor := make([]string, 0, 2)
+		if waitsForClosure {
+			waitingFor = append(waitingFor, fmt.Sprintf("route %s to reopen", route.Name))
+		} else if waitsForRoute {
+			waitingFor = append(waitingFor, fmt.Sprintf("route %s to clear", route.Name))
+		}
+		if waitsForPlatform {
+			waitingFor = append(waitingFor, fmt.Sprintf("a platform at station %s", g.StationNames[endingStationId]))
+		}
+		g.Moves = append(g.Moves, Move{
+			Kind:            WaitMove,
+			TimeTaken:       train.TravelTime,
+			Train:           *train,
+			DepartureTime:   train.TravelTime,
+			ArrivalTime:     departureTime,
+			Duration:        departureTime - train.TravelTime,
+			StartingStation: *g.Stations[startingStationId],
+			EndingStation:   *g.Stations[startingStationId],
+			PackagesCarried: train.PackagesCarried,
+			Reason:          "for " + strings.Join(waitingFor, " and "),
+		})
+		train.WaitUntil(departureTime)
+	}
+
+	move := Move{
+		TimeTaken:       train.TravelTime,
+		Train:           *train,
+		RouteName:       route.Name,
+		DepartureTime:   train.TravelTime,
+		ArrivalTime:     train.TravelTime + travelTime,
+		Duration:        travelTime,
+		StartingStation: *g.Stations[startingStationId],
+		EndingStation:   *g.Stations[endingStationId],
+		PackagesCarried: train.PackagesCarried,
+	}
+	g.occupy(route, train.Name, startingStationId, move.DepartureTime, move.ArrivalTime)
+	g.depart(train.Name, startingStationId, move.DepartureTime)
+	g.arrive(train.Name, endingStationId, move.ArrivalTime)
+	train.TravelTime = move.ArrivalTime
+	train.work(travelTime)
+	train.UpdatePosition(endingStationId)
+	return move
+}
+
+// waitForPackage holds a train at its current station until a package is ready to be picked up, tracking the wait as a move
+func (g *Graph) waitForPackage(trainName string, delivery Package) {
+	train := g.Trains[trainName]
+	if train.TravelTime >= delivery.ReadyTime {
+		return
+	}
+	g.Moves = append(g.Moves, Move{
+		Kind:            WaitMove,
+		TimeTaken:       train.TravelTime,
+		Train:           *train,
+		DepartureTime:   train.TravelTime,
+		ArrivalTime:     delivery.ReadyTime,
+		Duration:        delivery.ReadyTime - train.TravelTime,
+		StartingStation: *g.Stations[train.CurrentStationId],
+		EndingStation:   *g.Stations[train.CurrentStationId],
+		PackagesCarried: train.PackagesCarried,
+		Reason:          fmt.Sprintf("for package %s to be ready", delivery.Name),
+	})
+	train.WaitUntil(delivery.ReadyTime)
+}
+
+// dwell holds a train at its current station while packages are loaded and unloaded, tracking the dwell as a move
+// The dwell time is the handling time of the station for the packages plus the coupling time of the train
+func (g *Graph) dwell(trainName string, loadedPackages []Package, unloadedPackages []Package) {
+	if len(loadedPackages) == 0 && len(unloadedPackages) == 0 {
+		return
+	}
+	train := g.Trains[trainName]
+	station := g.Stations[train.CurrentStationId]
+	dwellTime := station.HandlingTimeFor(append(slices.Clone(loadedPackages), unloadedPackages...)) + train.CouplingTime
+	if dwellTime == 0 {
+		return
+	}
+	g.restIfDue(trainName, dwellTime)
+
+	handled := make([]string, 0, 2)
+	if len(loadedPackages) > 0 {
+		handled = append(handled, "loading "+packageNames(loadedPackages))
+	}
+	if len(unloadedPackages) > 0 {
+		handled = append(handled, "unloading "+packageNames(unloadedPackages))
+	}
+	g.Moves = append(g.Moves, Move{
+		Kind:            DwellMove,
+		TimeTaken:       train.TravelTime,
+		Train:           *train,
+		DepartureTime:   train.TravelTime,
+		ArrivalTime:     train.TravelTime + dwellTime,
+		Duration:        dwellTime,
+		StartingStation: *station,
+		EndingStation:   *station,
+		PackagesCarried: train.PackagesCarried,
+		Reason:          strings.Join(handled, " and "),
+	})
+	train.TravelTime += dwellTime
+	train.work(dwellTime)
+}
+
+// packageNames returns the names of a list of packages separated by commas
+func packageNames(packages []Package) string {
+	names := make([]string, 0, len(packages))
+	for _, delivery := range packages {
+		names = append(names, delivery.Name)
+	}
+	return strings.Join(names, ",")
+}
+
 // MoveToPickupPackage moves a train to pick up a package using the shortest path and updates its location and capacity
 // Tracks the move and adds it to the Moves slice
 func (g *Graph) MoveToPickupPackage(train Train, nearestPackage Package) {
 	// CASE: If the package to pickup is already at the train's current location
 	if train.CurrentStationId == nearestPackage.StartingStationId {
-		// Add the package and no need to update the time since no time is taken to pickup packages
+		// CASE: if the package is not ready yet, the train waits for it at the station
+		g.waitForPackage(train.Name, nearestPackage)
 		g.Trains[train.Name].AddPackage(nearestPackage)
 		// CASE: if we are also already in the same station that we can drop off the package
 		droppedPackages := g.Trains[train.Name].DropPackages()
 		g.Moves = append(g.Moves, Move{
-			TimeTaken:       g.Trains[train.Name].TravelTime, // no time taken to pickup package since the train is already there
+			TimeTaken:       g.Trains[train.Name].TravelTime, // the train is already there, the time taken to load the package is tracked as a dwell
 			Train:           train,
+			DepartureTime:   g.Trains[train.Name].TravelTime,
+			ArrivalTime:     g.Trains[train.Name].TravelTime,
 			StartingStation: *g.Stations[train.CurrentStationId],
 			EndingStation:   *g.Stations[train.CurrentStationId],
 			PackagesCarried: g.Trains[train.Name].PackagesCarried,
 			PackagesDropped: droppedPackages,
 		})
+		g.dwell(train.Name, []Package{nearestPackage}, droppedPackages)
 		return
 	}
 
 	// get the list of shortest path and adds it as moves
-	paths := g.GetShortestPath(train.CurrentStationId, nearestPackage.StartingStationId)
-	moves := make([]Move, 0)
-	currentTravelTime := g.Trains[train.Name].TravelTime
+	paths, detourAround := g.plannedPath(train.Name, train.CurrentStationId, nearestPackage.StartingStationId)
 	for i := 0; i < len(paths)-1; i++ {
 		currentStationId := paths[i]
 		nextStationId := paths[i+1]
 
 		// CASE: if along the way, we passed by a station that we can drop by packages
-		g.Trains[train.Name].UpdatePosition(currentStationId)
 		droppedPackages := g.Trains[train.Name].DropPackages()
+		g.dwell(train.Name, nil, droppedPackages)
 
-		moves = append(moves, Move{
-			TimeTaken:       currentTravelTime,
-			Train:           train,
-			StartingStation: *g.Stations[currentStationId],
-			EndingStation:   *g.Stations[nextStationId],
-			PackagesCarried: g.Trains[train.Name].PackagesCarried,
-			PackagesDropped: droppedPackages,
-		})
-		currentTravelTime += g.TravelTimeMatrix[currentStationId][nextStationId]
+		move := g.travelRoute(train.Name, currentStationId, nextStationId)
+		move.PackagesDropped = droppedPackages
+		move.DetourAround = detourAround
+		g.Moves = append(g.Moves, move)
 	}
-	g.Trains[train.Name].TravelTime = currentTravelTime
-	g.Trains[train.Name].UpdatePosition(nearestPackage.StartingStationId)
+	// CASE: if the train arrives before the package is ready, it waits for it at the station
+	g.waitForPackage(train.Name, nearestPackage)
 	g.Trains[train.Name].AddPackage(nearestPackage)
-	g.Moves = append(g.Moves, moves...)
+	g.dwell(train.Name, []Package{nearestPackage}, nil)
 }
 
 /*
@@ -293,66 +460,287 @@ Tracks the move and adds it to the Moves slice
 func (g *Graph) MoveToDropPackage(trainName string, packages []Package, destinationStationId int) {
 	train := g.Trains[trainName]
 	// CASE: If the train is alerady at the drop station
-	// CASE: If the package to pickup is already at the train's current location
 	if train.CurrentStationId == destinationStationId {
-		// Add the package and no need to update the time since no time is taken to pickup packages
 		g.Trains[train.Name].RemovePackages(packages)
 		g.Moves = append(g.Moves, Move{
-			TimeTaken:       g.Trains[train.Name].TravelTime, // no time taken to dropoff package since the train is already there
+			TimeTaken:       g.Trains[train.Name].TravelTime, // the train is already there, the time taken to unload the packages is tracked as a dwell
 			Train:           *train,
+			DepartureTime:   g.Trains[train.Name].TravelTime,
+			ArrivalTime:     g.Trains[train.Name].TravelTime,
 			StartingStation: *g.Stations[train.CurrentStationId],
 			EndingStation:   *g.Stations[train.CurrentStationId],
 			PackagesCarried: g.Trains[train.Name].PackagesCarried,
 			PackagesDropped: packages,
 		})
+		g.dwell(train.Name, nil, packages)
 		return
 	}
-	paths := make([]StationId, 0)
-	// https://en.wikipedia.org/wiki/Floyd%E2%80%93Warshall_algorithm#Path_reconstruction
-	start := g.Trains[trainName].CurrentStationId
-	end := destinationStationId
+	paths, detourAround := g.plannedPath(trainName, g.Trains[trainName].CurrentStationId, destinationStationId)
 
-	// Reconstruct the shortest path and tracks it to the Moves slice
-	// https://en.wikipedia.org/wiki/Floyd%E2%80%93Warshall_algorithm#Path_reconstruction
-	paths = append(paths, end)
-	for start != end {
-		end = g.TravelPathMatrix[start][end]
-		paths = append(paths, end)
+	for i := 0; i < len(paths)-1; i++ {
+		move := g.travelRoute(train.Name, paths[i], paths[i+1])
+		move.DetourAround = detourAround
+		g.Moves = append(g.Moves, move)
 	}
-	slices.Reverse(paths)
 
-	totalDroppedWeight := 0
+	g.Trains[train.Name].RemovePackages(packages)
+	// have to update again, since RemoveDroppedPackages will filter out some of the carried packages that are dropped
+	g.Moves[len(g.Moves)-1].PackagesCarried = g.Trains[train.Name].PackagesCarried
+	g.Moves[len(g.Moves)-1].PackagesDropped = packages
+	g.dwell(train.Name, nil, packages)
+}
+
+// canDeliver checks if a train can reach a package and the package can then be taken to its destination, which might not be the case with one-way routes,
+// routes the train is too heavy for once it carries the package, or if a station on the way is full with trains that have not departed yet
+// The package must also be compatible with every package the train is carrying, otherwise it is left for other trains or a later trip
+func (g *Graph) canDeliver(train *Train, delivery Package) bool {
+	if !g.canCombine(train, delivery) {
+		return false
+	}
+	if !g.canCarry(train.Speed, train.Load(), train.CurrentStationId, delivery.StartingStationId) || !g.canCarry(train.Speed, train.Load()+delivery.Weight, delivery.StartingStationId, delivery.EndingStationId) {
+		return false
+	}
+	// one-way routes could leave the train stranded at the destination with no way back to its depot
+	if train.HasDepot() && !g.IsReachable(delivery.EndingStationId, train.DepotStationId) {
+		return false
+	}
+	if !g.withinShift(train, delivery) {
+		return false
+	}
+	_, blocked := g.blockedStation(train.Name, delivery.StartingStationId)
+	return !blocked
+}
+
+// pickupTime returns the earliest time a train can pick up a package, including the time spent waiting for the package to be ready
+func (g *Graph) pickupTime(train *Train, delivery Package) int {
+	return max(train.TravelTime+g.travelTime(train.Name, train.CurrentStationId, delivery.StartingStationId), delivery.ReadyTime)
+}
+
+// slack returns how many minutes a package can be delayed by and still be delivered on time if the train picks it up next
+func (g *Graph) slack(train *Train, delivery Package) int {
+	return delivery.DueTime - g.deliveryTime(train, delivery)
+}
+
+// deliveryTime returns the earliest time a package can be delivered at if the train picks it up next and takes it straight to its destination
+func (g *Graph) deliveryTime(train *Train, delivery Package) int {
+	return g.pickupTime(train, delivery) + g.travelTime(train.Name, delivery.StartingStationId, delivery.EndingStationId)
+}
+
+// latenessAfter returns how many minutes late a package would be delivered if the train picked it up after reaching the given station at the given time
+func (g *Graph) latenessAfter(train *Train, delivery Package, stationId StationId, time int) int {
+	pickupTime := max(time+g.travelTime(train.Name, stationId, delivery.StartingStationId), delivery.ReadyTime)
+	return delivery.Lateness(pickupTime + g.travelTime(train.Name, delivery.StartingStationId, delivery.EndingStationId))
+}
+
+// mostUrgentPackages returns the 2 undelivered packages with a deadline that the train has the least slack for, the most urgent first
+// Two are kept so the most urgent package other than the one being picked up is always known
+func (g *Graph) mostUrgentPackages(train *Train, undeliveredPackages []Package) []Package {
+	urgent := make([]Package, 0, 3)
+	slacks := make([]int, 0, 3)
+	for _, delivery := range undeliveredPackages {
+		if !delivery.HasDeadline() {
+			continue
+		}
+		slack := g.slack(train, delivery)
+		i := len(urgent)
+		for i > 0 && slack < slacks[i-1] {
+			i--
+		}
+		urgent = slices.Insert(urgent, i, delivery)
+		slacks = slices.Insert(slacks, i, slack)
+		if len(urgent) > 2 {
+			urgent, slacks = urgent[:2], slacks[:2]
+		}
+	}
+	return urgent
+}
+
+/*
+pickupLateness estimates how many minutes late the packages with a deadline would be in total if the train picks up the package next
+This is the lateness of the package itself, of the packages the train carries and of the most urgent of the other undelivered packages once the package is delivered
+The carried packages due before the package are dropped off on the way, the others after it
+*/
+func (g *Graph) pickupLateness(train *Train, delivery Package, urgentPackages []Package) int {
+	pickupTime := g.pickupTime(train, delivery)
+	deliveryTime := g.deliveryTime(train, delivery)
+	lateness := delivery.Lateness(deliveryTime)
+	for _, carried := range train.PackagesCarried {
+		if !carried.HasDeadline() {
+			continue
+		}
+		if !delivery.HasDeadline() || carried.DueTime <= delivery.DueTime {
+			lateness += carried.Lateness(pickupTime + g.travelTime(train.Name, delivery.StartingStationId, carried.EndingStationId))
+		} else {
+			lateness += carried.Lateness(deliveryTime + g.travelTime(train.Name, delivery.EndingStationId, carried.EndingStationId))
+		}
+	}
+	for _, urgent := range urgentPackages {
+		if urgent.Name != delivery.Name {
+			lateness += g.latenessAfter(train, urgent, delivery.EndingStationId, deliveryTime)
+			break
+		}
+	}
+	return lateness
+}
+
+// dropoffLateness estimates how many minutes late the packages with a deadline would be in total if the train drops off the packages it carries before picking up any more
+func (g *Graph) dropoffLateness(train *Train, urgentPackages []Package) int {
+	lateness := 0
+	lastDropoffTime, lastDropoffStationId := train.TravelTime, train.CurrentStationId
+	for _, carried := range train.PackagesCarried {
+		dropoffTime := train.TravelTime + g.travelTime(train.Name, train.CurrentStationId, carried.EndingStationId)
+		lateness += carried.Lateness(dropoffTime)
+		if dropoffTime > lastDropoffTime {
+			lastDropoffTime, lastDropoffStationId = dropoffTime, carried.EndingStationId
+		}
+	}
+	if len(urgentPackages) > 0 {
+		lateness += g.latenessAfter(train, urgentPackages[0], lastDropoffStationId, lastDropoffTime)
+	}
+	return lateness
+}
+
+// pickupCandidate holds the keys a package is sorted by for a train to pick up next, worked out once before sorting
+type pickupCandidate struct {
+	Package     Package
+	Deliverable bool // see canDeliver
+	Lateness    int  // see pickupLateness, 0 if no package with a deadline is involved
+	Slack       int  // see slack, only for packages with a deadline
+	PickupTime  int  // how soon the train can pick up the package, weighted by its priority class and the way back to its depot
+}
+
+// pickupCandidates works out the sort keys of every undelivered package for a train, see comparePickups
+func (g *Graph) pickupCandidates(train *Train, undeliveredPackages []Package, urgentPackages []Package) []pickupCandidate {
+	hasDeadlines := len(urgentPackages) > 0 || slices.ContainsFunc(train.PackagesCarried, Package.HasDeadline)
+	candidates := make([]pickupCandidate, 0, len(undeliveredPackages))
+	for _, delivery := range undeliveredPackages {
+		candidate := pickupCandidate{Package: delivery, Deliverable: g.canDeliver(train, delivery)}
+		// the estimates only hold for packages the train can reach and deliver
+		if candidate.Deliverable {
+			if hasDeadlines {
+				candidate.Lateness = g.pickupLateness(train, delivery, urgentPackages)
+			}
+			if delivery.HasDeadline() {
+				candidate.Slack = g.slack(train, delivery)
+			}
+			// how soon the train can pick up the package is its distance from the train unless it is not ready yet
+			// packages of a higher priority class are brought forward by the priority weight
+			candidate.PickupTime = g.pickupTime(train, delivery) + g.returnTime(train, delivery) - g.priorityBonus(delivery.Priority)
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+/*
+comparePickups orders the undelivered packages by how suitable they are for a train to pick up next
+Packages the train cannot deliver are sorted last, then packages of a higher priority class first if the priority is strict
+Packages that would leave the packages with a deadline the least late in total come next, so a package is not picked up on time at the cost of a later one
+Then packages with a deadline, the ones with the least slack first
+The remaining packages are sorted by how soon the train can pick them up, weighted by their priority class, then by destination
+For a train with a depot, the time it takes to get back to the depot from the destination is added, favouring packages heading its way
+*/
+func (g *Graph) comparePickups(candidateX pickupCandidate, candidateY pickupCandidate) int {
+	packageX, packageY := candidateX.Package, candidateY.Package
+	if candidateX.Deliverable != candidateY.Deliverable {
+		if candidateX.Deliverable {
+			return -1
+		}
+		return 1
+	}
+	if g.PriorityWeight == StrictPriority && packageX.Priority != packageY.Priority {
+		return packageY.Priority.Rank() - packageX.Priority.Rank()
+	}
+	if candidateX.Lateness != candidateY.Lateness {
+		if candidateX.Lateness < candidateY.Lateness {
+			return -1
+		}
+		return 1
+	}
+	if packageX.HasDeadline() != packageY.HasDeadline() {
+		if packageX.HasDeadline() {
+			return -1
+		}
+		return 1
+	}
+	if candidateX.Slack != candidateY.Slack {
+		return candidateX.Slack - candidateY.Slack
+	}
+	if candidateX.PickupTime != candidateY.PickupTime {
+		return candidateX.PickupTime - candidateY.PickupTime
+	}
+	// this will encourage the sorting to group up packages with similar destinations together
+	return packageX.EndingStationId - packageY.EndingStationId
+}
+
+// priorityBonus returns how many minutes closer a package of the given class is considered to be, compared to an economy package
+func (g *Graph) priorityBonus(class PriorityClass) int {
+	if g.PriorityWeight == StrictPriority {
+		return 0
+	}
+	return g.PriorityWeight * class.Rank()
+}
+
+// earliestDueTime returns the earliest due time of a list of packages, or MaxInt if none of them has a deadline
+func earliestDueTime(packages []Package) int {
+	dueTime := MaxInt
 	for _, delivery := range packages {
-		totalDroppedWeight += delivery.Weight
+		if delivery.HasDeadline() {
+			dueTime = min(dueTime, delivery.DueTime)
+		}
 	}
+	return dueTime
+}
 
-	moves := make([]Move, 0)
-	currentTravelTime := g.Trains[train.Name].TravelTime
-	for i := 0; i < len(paths)-1; i++ {
-		currentStationId := paths[i]
-		nextStationId := paths[i+1]
-		moves = append(moves, Move{
-			TimeTaken:       currentTravelTime,
-			Train:           *train,
-			StartingStation: *g.Stations[currentStationId],
-			EndingStation:   *g.Stations[nextStationId],
-			PackagesCarried: g.Trains[train.Name].PackagesCarried,
-		})
-		currentTravelTime += g.TravelTimeMatrix[currentStationId][nextStationId]
+/*
+compareDropoffs orders the destinations a train has packages for by which one it should visit next
+The station the train is already at comes first, then the destination holding the highest priority class if the priority is strict
+Destinations of packages with a deadline come next, the most urgent one first, then the closest weighted by priority class
+*/
+func (g *Graph) compareDropoffs(train *Train, destinationX StationId, destinationY StationId, packagesByDestination map[StationId][]Package) int {
+	currentStationId := train.CurrentStationId
+	if (destinationX == currentStationId) != (destinationY == currentStationId) {
+		if destinationX == currentStationId {
+			return -1
+		}
+		return 1
+	}
+	packagesX := packagesByDestination[destinationX]
+	packagesY := packagesByDestination[destinationY]
+	if g.PriorityWeight == StrictPriority {
+		if rankX, rankY := highestPriority(packagesX).Rank(), highestPriority(packagesY).Rank(); rankX != rankY {
+			return rankY - rankX
+		}
 	}
+	if dueTimeX, dueTimeY := earliestDueTime(packagesX), earliestDueTime(packagesY); dueTimeX != dueTimeY {
+		if dueTimeX < dueTimeY {
+			return -1
+		}
+		return 1
+	}
+	travelTimeX := g.travelTime(train.Name, currentStationId, destinationX) - g.priorityBonus(highestPriority(packagesX))
+	travelTimeY := g.travelTime(train.Name, currentStationId, destinationY) - g.priorityBonus(highestPriority(packagesY))
+	if travelTimeX != travelTimeY {
+		return travelTimeX - travelTimeY
+	}
+	// go returns the keys in random order, break ties by station id to make it determinstic
+	return destinationX - destinationY
+}
 
-	g.Trains[train.Name].TravelTime = currentTravelTime
-	g.Trains[train.Name].UpdatePosition(destinationStationId)
-	g.Trains[train.Name].RemovePackages(packages)
-	// have to update again, since RemoveDroppedPackages will filter out some of the carried packages that are dropped
-	moves[len(moves)-1].PackagesCarried = g.Trains[train.Name].PackagesCarried
-	moves[len(moves)-1].PackagesDropped = packages
-	g.Moves = append(g.Moves, moves...)
+// highestPriority returns the most urgent priority class of a list of packages
+func highestPriority(packages []Package) PriorityClass {
+	highest := EconomyPriority
+	for _, delivery := range packages {
+		if delivery.Priority.Rank() > highest.Rank() {
+			highest = delivery.Priority
+		}
+	}
+	return highest
 }
 
 /*
 Deliver is the main entry function for delivering all packages to their destinations
-It manages assignment for each train (using a max heap) a set of packages based on the package's weight and distance and attempts to deliver all packages to their destinations
+It manages assignment for each train (using a max heap) a set of packages based on the package's weight, distance and deadline and attempts to deliver all packages to their destinations
 It has 2 phases: the pickup phase and dropoff phase
 Pickup phase assigns each train a set of packages to pick up
 Dropoff phase directs each train to dropoff its set of packages to their destinations before it can pick up again
@@ -373,10 +761,22 @@ func (g *Graph) Deliver() error {
 	slices.Sort(trainNames)
 	for _, trainName := range trainNames {
 		heap.Push(trainsQueue, *g.Trains[trainName])
+		// every train takes up a platform at the station it starts at
+		g.arrive(trainName, g.Trains[trainName].CurrentStationId, g.Trains[trainName].TravelTime)
 	}
 
+	// trains that cannot travel any further because a station on their way is full, to explain why packages could not be delivered
+	blocks := make(map[string]platformBlock, 0)
+	// trains whose crew would work past the end of its shift to deliver the next package
+	endedShifts := make([]string, 0)
+
 	// if we haven't delivered all the packages yet
 	for len(undeliveredPackages) > 0 {
+		// empty trains kept from picking up by a full station, retried once the other trains have moved
+		blockedTrains := make([]Train, 0)
+		// whether any train picked up or dropped off a package this round
+		moved := false
+
 		// NOTE: PICKUP phase
 		// assign all the trains (if possible) first
 		for len(*trainsQueue) > 0 {
@@ -386,27 +786,40 @@ func (g *Graph) Deliver() error {
 			assignableTrain := heap.Pop(trainsQueue).(Train)
 			train := g.Trains[assignableTrain.Name]
 
-			slices.SortFunc(undeliveredPackages, func(packageX, packageY Package) int {
-				// first sort by their package pickup distance from the train
-				packageXDistanceToTrain := g.TravelTimeMatrix[train.CurrentStationId][packageX.StartingStationId]
-				packageYDistanceToTrain := g.TravelTimeMatrix[train.CurrentStationId][packageY.StartingStationId]
-				if packageXDistanceToTrain != packageYDistanceToTrain {
-					return packageXDistanceToTrain - packageYDistanceToTrain
-				} else {
-					// this will encourage the sorting to group up packages with similar destinations together
-					return packageX.EndingStationId - packageY.EndingStationId
-				}
-
-			})
+			urgentPackages := g.mostUrgentPackages(train, undeliveredPackages)
+			candidates := g.pickupCandidates(train, undeliveredPackages, urgentPackages)
+			slices.SortFunc(candidates, g.comparePickups)
+			for i, candidate := range candidates {
+				undeliveredPackages[i] = candidate.Package
+			}
 			nearestPackage := undeliveredPackages[0]
 
-			if nearestPackage.Weight > g.Trains[train.Name].Capacity {
-				// NOTE: this train cannot pick up anymore packages, package might be too heavy or the train is already filled with packages
+			if !g.Trains[train.Name].CanFit(nearestPackage) {
+				// NOTE: this train cannot pick up anymore packages, package might be too heavy or bulky or the train is already filled with packages
+				continue
+			} else if len(train.PackagesCarried) > 0 && candidates[0].Deliverable && candidates[0].Lateness > g.dropoffLateness(train, urgentPackages) {
+				// NOTE: picking up the package would leave the packages with a deadline later than dropping off the carried packages first, so the train drops them off first
+				continue
+			} else if !candidates[0].Deliverable {
+				// NOTE: one-way routes or full stations can leave the package out of reach for this train, since unreachable packages are sorted last there is nothing left for it to pick up
+				block, blocked := g.blockedStation(train.Name, nearestPackage.StartingStationId)
+				if blocked {
+					blocks[train.Name] = block
+				}
+				// NOTE: the train is done for the day, the package is left for the other trains
+				if !g.withinShift(train, nearestPackage) {
+					endedShifts = append(endedShifts, train.Name)
+				} else if blocked && len(train.PackagesCarried) == 0 {
+					// NOTE: the dropoff phase only picks up trains carrying packages, so an empty train would never be tried again
+					blockedTrains = append(blockedTrains, *train)
+				}
 				continue
 			} else {
 				heap.Push(trainsQueue, *g.Trains[train.Name])
 			}
 
+			delete(blocks, train.Name)
+			moved = true
 			g.MoveToPickupPackage(*train, nearestPackage)
 			// this package has been picked up and can be delivered, update the undeliveredPackages
 			undeliveredPackages = undeliveredPackages[1:]
@@ -442,13 +855,15 @@ func (g *Graph) Deliver() error {
 			}
 		}
 
+		// number of trains in a row that could not drop off any packages because a station on their way is full
+		deferredTrains := 0
 		for len(assignedTrains) > 0 {
 			assignedTrain := assignedTrains[0]
 			assignedTrains = assignedTrains[1:]
 
 			// track common destination packages
 			packagesByDestinationMap := make(map[StationId][]Package, 0)
-			for _, packageCarried := range assignedTrain.PackagesCarried {
+			for _, packageCarried := range g.Trains[assignedTrain.Name].PackagesCarried {
 				if _, exists := packagesByDestinationMap[packageCarried.EndingStationId]; !exists {
 					packagesByDestinationMap[packageCarried.EndingStationId] = make([]Package, 0)
 				}
@@ -456,8 +871,46 @@ func (g *Graph) Deliver() error {
 			}
 
 			// for each package to be delivered for this train, choose the package that can be delivered earliest (use the matrix)
-			for packageDestinationStationId, carriedPackages := range packagesByDestinationMap {
-				g.MoveToDropPackage(assignedTrain.Name, carriedPackages, packageDestinationStationId)
+			for len(packagesByDestinationMap) > 0 {
+				currentStationId := g.Trains[assignedTrain.Name].CurrentStationId
+				nextDestinationStationId := -1
+				nextReachable := false
+				for packageDestinationStationId := range packagesByDestinationMap {
+					// NOTE: routes with a load limit can keep a heavy train from a destination until it has dropped off other packages first
+					reachable := g.travelTime(assignedTrain.Name, currentStationId, packageDestinationStationId) < MaxInt
+					if nextDestinationStationId < 0 || (reachable && !nextReachable) ||
+						(reachable == nextReachable && g.compareDropoffs(g.Trains[assignedTrain.Name], packageDestinationStationId, nextDestinationStationId, packagesByDestinationMap) < 0) {
+						nextDestinationStationId = packageDestinationStationId
+						nextReachable = reachable
+					}
+				}
+
+				if !g.IsReachable(currentStationId, nextDestinationStationId) {
+					return fmt.Errorf("train %s cannot reach station %s from station %s to drop off its packages", assignedTrain.Name, g.StationNames[nextDestinationStationId], g.StationNames[currentStationId])
+				}
+				if !nextReachable {
+					return fmt.Errorf("train %s carrying %dkg is too heavy for the routes from station %s to station %s to drop off its packages", assignedTrain.Name, g.Trains[assignedTrain.Name].Load(), g.StationNames[currentStationId], g.StationNames[nextDestinationStationId])
+				}
+				// CASE: a station on the way is full, let the other trains move first and try again after them
+				if block, blocked := g.blockedStation(assignedTrain.Name, nextDestinationStationId); blocked {
+					blocks[assignedTrain.Name] = block
+					break
+				}
+				delete(blocks, assignedTrain.Name)
+				deferredTrains = 0
+				moved = true
+				g.MoveToDropPackage(assignedTrain.Name, packagesByDestinationMap[nextDestinationStationId], nextDestinationStationId)
+				delete(packagesByDestinationMap, nextDestinationStationId)
+			}
+
+			if len(packagesByDestinationMap) > 0 {
+				// NOTE: if none of the remaining trains can move either, they are waiting on each other's platforms
+				deferredTrains++
+				if deferredTrains > len(assignedTrains) {
+					return g.deadlockError(blocks)
+				}
+				assignedTrains = append(assignedTrains, *g.Trains[assignedTrain.Name])
+				continue
 			}
 
 			assignedTrain.PackagesCarried = []Package{}
@@ -467,12 +920,27 @@ func (g *Graph) Deliver() error {
 			}
 		}
 
+		// CASE: the trains in the way of the blocked trains might have moved on, so they try again
+		// if no train moved this round, the queue stays empty and the blocked trains are reported below
+		if moved {
+			for _, blockedTrain := range blockedTrains {
+				heap.Push(trainsQueue, *g.Trains[blockedTrain.Name])
+			}
+		}
+
 		// CASE: There are still packages to deliver, but no trains can deliver them
 		// Because they might not have enough capacity
 		if len(*trainsQueue) == 0 && len(undeliveredPackages) > 0 {
+			if len(blocks) > 0 {
+				return g.deadlockError(blocks)
+			}
+			if len(endedShifts) > 0 {
+				slices.Sort(endedShifts)
+				return fmt.Errorf("there are still packages to deliver, but the crews of the trains that could deliver them would work past the end of their shift: %s", strings.Join(slices.Compact(endedShifts), ", "))
+			}
 			return fmt.Errorf("there are still packages to deliver, but no trains can deliver them :(")
 		}
 	}
 
-	return nil
+	return g.returnToDepots()
 }
@@ -0,0 +1,99 @@
+package graph
+
+import (
+	"slices"
+)
+
+// AllowsLoad checks if a train carrying packages of the given total weight in kilograms may travel the route
+func (route Route) AllowsLoad(load int) bool {
+	return route.MaxLoad == 0 || load <= route.MaxLoad
+}
+
+// limitsLoad checks if any route is closed to trains carrying the given weight, the travel matrices only hold for trains under every limit
+func (g *Graph) limitsLoad(load int) bool {
+	return g.lowestMaxLoad > 0 && load > g.lowestMaxLoad
+}
+
+// routesAllowing returns the parallel routes between 2 adjacent stations that a train carrying the given load may travel
+// The paths are planned around the routes a train is too heavy for, but if none of them allows the load all of them are returned,
+// so the train still takes the fastest route instead of being left without one
+func (g *Graph) routesAllowing(load int, startingStationId StationId, endingStationId StationId) []*Route {
+	routes := make([]*Route, 0, len(g.Routes[startingStationId][endingStationId]))
+	for _, route := range g.Routes[startingStationId][endingStationId] {
+		if route.AllowsLoad(load) {
+			routes = append(routes, route)
+		}
+	}
+	if len(routes) == 0 {
+		return g.Routes[startingStationId][endingStationId]
+	}
+	return routes
+}
+
+/*
+loadLimitedPath finds the fastest path between 2 stations for a train with the given speed factor and load, using Dijkstra's algorithm
+Only the routes allowing the load are travelled, so the path might take a detour around them
+Returns the path and its travel time, or nil and MaxInt if the ending station cannot be reached with the load
+Time complexity: O(V^2 + E) where V is the number of stations and E the number of routes
+*/
+func (g *Graph) loadLimitedPath(speed float64, load int, startingStationId StationId, endingStationId StationId) ([]StationId, int) {
+	travelTimes := make(map[StationId]int, len(g.Stations))
+	previousStations := make(map[StationId]StationId, len(g.Stations))
+	visited := make(map[StationId]bool, len(g.Stations))
+	for stationId := range g.Stations {
+		travelTimes[stationId] = MaxInt
+	}
+	travelTimes[startingStationId] = 0
+
+	stationIds := make([]StationId, 0, len(g.Stations))
+	for stationId := range g.Stations {
+		stationIds = append(stationIds, stationId)
+	}
+	slices.Sort(stationIds)
+
+	for {
+		// visit the closest station not visited yet, the lowest id first to keep the paths stable
+		currentStationId := -1
+		for _, stationId := range stationIds {
+			if !visited[stationId] && travelTimes[stationId] < MaxInt && (currentStationId < 0 || travelTimes[stationId] < travelTimes[currentStationId]) {
+				currentStationId = stationId
+			}
+		}
+		if currentStationId < 0 || currentStationId == endingStationId {
+			break
+		}
+		visited[currentStationId] = true
+
+		for adjacentStationId, routes := range g.Routes[currentStationId] {
+			for _, route := range routes {
+				if !route.AllowsLoad(load) {
+					continue
+				}
+				if travelTime := travelTimes[currentStationId] + route.TravelTimeAt(speed); travelTime < travelTimes[adjacentStationId] {
+					travelTimes[adjacentStationId] = travelTime
+					previousStations[adjacentStationId] = currentStationId
+				}
+			}
+		}
+	}
+
+	if travelTimes[endingStationId] == MaxInt {
+		return nil, MaxInt
+	}
+	paths := []StationId{endingStationId}
+	for stationId := endingStationId; stationId != startingStationId; {
+		stationId = previousStations[stationId]
+		paths = append(paths, stationId)
+	}
+	slices.Reverse(paths)
+	return paths, travelTimes[endingStationId]
+}
+
+// canCarry checks if a train with the given speed factor can travel between 2 stations carrying the given load
+func (g *Graph) canCarry(speed float64, load int, startingStationId StationId, endingStationId StationId) bool {
+	if !g.limitsLoad(load) {
+		return g.IsReachable(startingStationId, endingStationId)
+	}
+	_, travelTime := g.loadLimitedPath(speed, load, startingStationId, endingStationId)
+	return travelTime < MaxInt
+}
@@ -0,0 +1,18 @@
+package graph
+
+import "testing"
+
+func TestTravelRouteTooHeavyForEveryRoute(t *testing.T) {
+	g, err := NewGraph([]string{"A", "B"}, []string{"E1,A,B,10,max-load=1", "E2,A,B,20,max-load=2"}, []string{"K1,5,A,B"}, []string{"Q1,5,A"}, nil)
+	if err != nil {
+		t.Fatalf("unable to build the graph: %v", err)
+	}
+	g.BuildTravelTimeMatrix()
+	g.Trains["Q1"].AddPackage(g.Deliveries[0])
+
+	// the planner keeps heavy trains off these routes, but a train sent down them anyway takes the fastest one instead of having no route
+	move := g.travelRoute("Q1", 0, 1)
+	if move.RouteName != "E1" || move.Duration != 10 {
+		t.Errorf("expected the train to take route E1 in 10 minutes, found route %q in %d minutes", move.RouteName, move.Duration)
+	}
+}
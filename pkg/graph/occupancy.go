@@ -0,0 +1,403 @@
+package graph
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Track layouts accepted by the track attribute of a route, e.g. E1,A,B,10,track=single
+const (
+	SingleTrack = "single" // trains cannot travel the route in opposite directions at the same time
+	DoubleTrack = "double" // each direction has its own track, which is the default
+)
+
+// stationOccupation records a train standing at a station, from its arrival until its departure
+type stationOccupation struct {
+	Train         string
+	ArrivalTime   int
+	DepartureTime int // MaxInt while the train has not departed yet
+}
+
+// occupiesAt checks if the train takes up a platform at the given time, a platform is free again from the time the train departs
+// A train passing through the station, departing the minute it arrives, takes up a platform for that minute
+func (occupation stationOccupation) occupiesAt(time int) bool {
+	return occupation.ArrivalTime <= time && (time < occupation.DepartureTime || time == occupation.ArrivalTime)
+}
+
+// freeFrom returns the time the train's platform is free again, the minute after it arrives if it passes through the station
+func (occupation stationOccupation) freeFrom() int {
+	return max(occupation.DepartureTime, occupation.ArrivalTime+1)
+}
+
+// routeOccupation records a train travelling a route in one direction during a period of time
+type routeOccupation struct {
+	Train         string
+	From          StationId
+	DepartureTime int
+	ArrivalTime   int
+}
+
+// overlaps checks if the occupation shares any time with the period from departureTime to arrivalTime
+func (occupation routeOccupation) overlaps(departureTime int, arrivalTime int) bool {
+	return occupation.DepartureTime < arrivalTime && departureTime < occupation.ArrivalTime
+}
+
+// isFree checks if a train can travel a route from a station during a period without exceeding its capacity
+// or meeting a train travelling the other way on a single track
+func (route *Route) isFree(occupations []routeOccupation, from StationId, departureTime int, arrivalTime int) bool {
+	sameDirection := 0
+	for _, occupation := range occupations {
+		if !occupation.overlaps(departureTime, arrivalTime) {
+			continue
+		}
+		if occupation.From != from && route.SingleTrack {
+			return false
+		}
+		if occupation.From == from {
+			sameDirection++
+		}
+	}
+	return route.Capacity == 0 || sameDirection < route.Capacity
+}
+
+// earliestDeparture returns the earliest time from the given time a train taking the given travel time can depart on a route from a station without a conflict
+// Routes only free up when a train reaches the end of it or when they reopen, so the candidates are the given time, the arrival times of the other trains and the ends of the closures
+func (g *Graph) earliestDeparture(route *Route, from StationId, time int, travelTime int) int {
+	occupations := g.occupations[route.Name]
+	candidates := []int{time}
+	for _, occupation := range occupations {
+		if occupation.ArrivalTime > time {
+			candidates = append(candidates, occupation.ArrivalTime)
+		}
+	}
+	for _, closure := range route.Closures {
+		if closure.To > time {
+			candidates = append(candidates, closure.To)
+		}
+	}
+	slices.Sort(candidates)
+	for _, candidate := range candidates {
+		if route.isFree(occupations, from, candidate, candidate+travelTime) && route.isOpen(candidate, candidate+travelTime) {
+			return candidate
+		}
+	}
+	// the latest arrival or reopening always frees up the route, this is never reached
+	return candidates[len(candidates)-1]
+}
+
+// occupy reserves a route for a train travelling it from a station during a period of time
+func (g *Graph) occupy(route *Route, trainName string, from StationId, departureTime int, arrivalTime int) {
+	if g.occupations == nil {
+		g.occupations = make(map[string][]routeOccupation, 0)
+	}
+	g.occupations[route.Name] = append(g.occupations[route.Name], routeOccupation{
+		Train:         trainName,
+		From:          from,
+		DepartureTime: departureTime,
+		ArrivalTime:   arrivalTime,
+	})
+}
+
+// Conflict describes trains using a route or a station at the same time in a way its capacity does not allow
+type Conflict struct {
+	RouteName   string // route the conflict happens on, empty if it happens at a station
+	StationName string // station the conflict happens at, empty if it happens on a route
+	Time        int    // time in minutes the conflict starts at
+	Trains      []string
+	Reason      string
+}
+
+// Location returns the route or station the conflict happens at, e.g. route E1
+func (conflict Conflict) Location() string {
+	if conflict.RouteName != "" {
+		return "route " + conflict.RouteName
+	}
+	return "station " + conflict.StationName
+}
+
+func (conflict Conflict) String() string {
+	return fmt.Sprintf("%s at %d minutes: trains %s %s", conflict.Location(), conflict.Time, strings.Join(conflict.Trains, ", "), conflict.Reason)
+}
+
+/*
+Conflicts checks the planned moves against the capacity and track layout of each route and the platforms of each station
+and returns every violation found, routes first
+Deliver makes trains wait for routes to clear and for platforms to free up, so this is a report for plans that were changed or built by other means
+*/
+func (g *Graph) Conflicts() []Conflict {
+	return append(g.routeConflicts(), g.platformConflicts()...)
+}
+
+// routeConflicts returns the trains using a route beyond its capacity or in opposite directions on a single track
+func (g *Graph) routeConflicts() []Conflict {
+	routesByName := make(map[string]*Route, 0)
+	for _, adjacentRoutes := range g.Routes {
+		for _, routes := range adjacentRoutes {
+			for _, route := range routes {
+				routesByName[route.Name] = route
+			}
+		}
+	}
+
+	movesByRoute := make(map[string][]Move, 0)
+	routeNames := make([]string, 0)
+	for _, move := range g.Moves {
+		if move.Kind != TravelMove || move.RouteName == "" {
+			continue
+		}
+		if _, exists := movesByRoute[move.RouteName]; !exists {
+			routeNames = append(routeNames, move.RouteName)
+		}
+		movesByRoute[move.RouteName] = append(movesByRoute[move.RouteName], move)
+	}
+	slices.Sort(routeNames)
+
+	conflicts := make([]Conflict, 0)
+	for _, routeName := range routeNames {
+		route := routesByName[routeName]
+		moves := movesByRoute[routeName]
+		slices.SortStableFunc(moves, func(a Move, b Move) int {
+			return a.DepartureTime - b.DepartureTime
+		})
+		for i, move := range moves {
+			sameDirection := []string{move.Train.Name}
+			for _, other := range moves[:i] {
+				if other.ArrivalTime <= move.DepartureTime {
+					continue
+				}
+				if other.StartingStation.Id == move.StartingStation.Id {
+					sameDirection = append(sameDirection, other.Train.Name)
+				} else if route.SingleTrack {
+					conflicts = append(conflicts, Conflict{
+						RouteName: routeName,
+						Time:      move.DepartureTime,
+						Trains:    []string{other.Train.Name, move.Train.Name},
+						Reason:    "travel in opposite directions on a single track",
+					})
+				}
+			}
+			if route.Capacity > 0 && len(sameDirection) > route.Capacity {
+				slices.Reverse(sameDirection)
+				conflicts = append(conflicts, Conflict{
+					RouteName: routeName,
+					Time:      move.DepartureTime,
+					Trains:    sameDirection,
+					Reason:    fmt.Sprintf("travel in the same direction, exceeding the capacity of %d trains", route.Capacity),
+				})
+			}
+		}
+	}
+	return conflicts
+}
+
+// arrive records a train arriving at a station, it takes up one of its platforms until it departs
+func (g *Graph) arrive(trainName string, stationId StationId, time int) {
+	if g.stationOccupations == nil {
+		g.stationOccupations = make(map[StationId][]stationOccupation, 0)
+	}
+	g.stationOccupations[stationId] = append(g.stationOccupations[stationId], stationOccupation{
+		Train:         trainName,
+		ArrivalTime:   time,
+		DepartureTime: MaxInt,
+	})
+}
+
+// depart records a train departing from a station, freeing up its platform
+func (g *Graph) depart(trainName string, stationId StationId, time int) {
+	occupations := g.stationOccupations[stationId]
+	for i := range occupations {
+		if occupations[i].Train == trainName && occupations[i].DepartureTime == MaxInt {
+			occupations[i].DepartureTime = time
+		}
+	}
+}
+
+// platformsTaken returns the number of platforms of a station taken up by other trains at the given time
+func (g *Graph) platformsTaken(stationId StationId, trainName string, time int) int {
+	taken := 0
+	for _, occupation := range g.stationOccupations[stationId] {
+		if occupation.Train != trainName && occupation.occupiesAt(time) {
+			taken++
+		}
+	}
+	return taken
+}
+
+/*
+earliestArrival returns the earliest time from the given time a train can arrive at a station with a free platform
+The train keeps its platform until its next move is planned, so the platform has to stay free for the trains planned to arrive later as well
+Platforms only free up when a train departs, MaxInt is returned if the station is full with trains that have not departed yet
+*/
+func (g *Graph) earliestArrival(stationId StationId, trainName string, time int) int {
+	platforms := g.Stations[stationId].Platforms
+	if platforms == 0 {
+		return time
+	}
+	candidates := []int{time}
+	for _, occupation := range g.stationOccupations[stationId] {
+		if occupation.Train != trainName && occupation.freeFrom() > time && occupation.DepartureTime < MaxInt {
+			candidates = append(candidates, occupation.freeFrom())
+		}
+	}
+	slices.Sort(candidates)
+	for _, candidate := range candidates {
+		if g.platformFreeFrom(stationId, trainName, candidate, platforms) {
+			return candidate
+		}
+	}
+	return MaxInt
+}
+
+// platformFreeFrom checks if a platform of a station stays free from the given time on, with every train planned to arrive after it
+func (g *Graph) platformFreeFrom(stationId StationId, trainName string, time int, platforms int) bool {
+	if g.platformsTaken(stationId, trainName, time) >= platforms {
+		return false
+	}
+	for _, occupation := range g.stationOccupations[stationId] {
+		if occupation.Train != trainName && occupation.ArrivalTime > time && g.platformsTaken(stationId, trainName, occupation.ArrivalTime) >= platforms {
+			return false
+		}
+	}
+	return true
+}
+
+// parkedTrains returns the other trains standing at a station that have not departed yet
+func (g *Graph) parkedTrains(stationId StationId, trainName string) []string {
+	parked := make([]string, 0)
+	for _, occupation := range g.stationOccupations[stationId] {
+		if occupation.Train != trainName && occupation.DepartureTime == MaxInt {
+			parked = append(parked, occupation.Train)
+		}
+	}
+	return parked
+}
+
+// platformBlock describes a train that cannot travel to a station because a station on the way is full with parked trains
+type platformBlock struct {
+	StationId StationId
+	Trains    []string // trains parked at the station
+}
+
+// blockedStation checks the shortest path of a train to a station for a station whose platforms are all taken by trains that have not departed yet
+// The train would have to wait there until one of them departs, which might never happen
+func (g *Graph) blockedStation(trainName string, destinationStationId StationId) (platformBlock, bool) {
+	paths := g.shortestPathFor(trainName, g.Trains[trainName].CurrentStationId, destinationStationId)
+	for i := 1; i < len(paths); i++ {
+		platforms := g.Stations[paths[i]].Platforms
+		if parked := g.parkedTrains(paths[i], trainName); platforms > 0 && len(parked) >= platforms {
+			return platformBlock{StationId: paths[i], Trains: parked}, true
+		}
+	}
+	return platformBlock{}, false
+}
+
+/*
+deadlockError explains why trains could not travel any further because of full stations
+If the blocked trains wait on each other's platforms, the cycle is reported as a deadlock
+otherwise the first blocked train is reported with the trains parked in its way
+*/
+func (g *Graph) deadlockError(blocks map[string]platformBlock) error {
+	trainNames := make([]string, 0, len(blocks))
+	for trainName := range blocks {
+		trainNames = append(trainNames, trainName)
+	}
+	slices.Sort(trainNames)
+
+	for _, trainName := range trainNames {
+		// follow the trains parked in the way of the blocked trains, until a train is seen again
+		cycle := []string{trainName}
+		for current := trainName; ; {
+			block := blocks[current]
+			next := ""
+			for _, parkedTrain := range block.Trains {
+				if _, blocked := blocks[parkedTrain]; blocked {
+					next = parkedTrain
+					break
+				}
+			}
+			if next == "" {
+				break
+			}
+			if start := slices.Index(cycle, next); start >= 0 {
+				waits := make([]string, 0)
+				for i, waiting := range cycle[start:] {
+					holder := cycle[start:][(i+1)%len(cycle[start:])]
+					waits = append(waits, fmt.Sprintf("train %s waits for a platform at station %s taken by train %s", waiting, g.StationNames[blocks[waiting].StationId], holder))
+				}
+				return fmt.Errorf("deadlock: %s", strings.Join(waits, ", "))
+			}
+			cycle = append(cycle, next)
+			current = next
+		}
+	}
+
+	block := blocks[trainNames[0]]
+	return fmt.Errorf("train %s cannot travel any further, every platform at station %s is taken by trains that do not depart: %s", trainNames[0], g.StationNames[block.StationId], strings.Join(block.Trains, ", "))
+}
+
+// platformConflicts returns the trains standing at a station at the same time beyond its number of platforms
+func (g *Graph) platformConflicts() []Conflict {
+	trainNames := make([]string, 0, len(g.Trains))
+	for trainName := range g.Trains {
+		trainNames = append(trainNames, trainName)
+	}
+	slices.Sort(trainNames)
+
+	// rebuild the time each train spends at each station from its moves
+	movesByTrain := make(map[string][]Move, 0)
+	for _, move := range g.Moves {
+		if move.Kind == TravelMove && move.RouteName != "" {
+			movesByTrain[move.Train.Name] = append(movesByTrain[move.Train.Name], move)
+		}
+	}
+	occupations := make(map[StationId][]stationOccupation, 0)
+	for _, trainName := range trainNames {
+		stationId := g.Trains[trainName].CurrentStationId
+		arrivalTime := 0
+		if moves := movesByTrain[trainName]; len(moves) > 0 {
+			stationId = moves[0].StartingStation.Id
+		}
+		for _, move := range movesByTrain[trainName] {
+			occupations[stationId] = append(occupations[stationId], stationOccupation{Train: trainName, ArrivalTime: arrivalTime, DepartureTime: move.DepartureTime})
+			stationId = move.EndingStation.Id
+			arrivalTime = move.ArrivalTime
+		}
+		occupations[stationId] = append(occupations[stationId], stationOccupation{Train: trainName, ArrivalTime: arrivalTime, DepartureTime: MaxInt})
+	}
+
+	stationIds := make([]StationId, 0, len(occupations))
+	for stationId := range occupations {
+		stationIds = append(stationIds, stationId)
+	}
+	slices.Sort(stationIds)
+
+	conflicts := make([]Conflict, 0)
+	for _, stationId := range stationIds {
+		platforms := g.Stations[stationId].Platforms
+		if platforms == 0 {
+			continue
+		}
+		stationOccupations := occupations[stationId]
+		slices.SortStableFunc(stationOccupations, func(a stationOccupation, b stationOccupation) int {
+			return a.ArrivalTime - b.ArrivalTime
+		})
+		for i, occupation := range stationOccupations {
+			trains := make([]string, 0)
+			for _, other := range stationOccupations[:i] {
+				if other.occupiesAt(occupation.ArrivalTime) {
+					trains = append(trains, other.Train)
+				}
+			}
+			if len(trains) >= platforms {
+				conflicts = append(conflicts, Conflict{
+					StationName: g.StationNames[stationId],
+					Time:        occupation.ArrivalTime,
+					Trains:      append(trains, occupation.Train),
+					Reason:      fmt.Sprintf("stand at the station at the same time, exceeding its %d platforms", platforms),
+				})
+			}
+		}
+	}
+	return conflicts
+}
@@ -0,0 +1,122 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// expectedWait describes a wait move a train should make, the reason only has to contain the given text
+type expectedWait struct {
+	train  string
+	reason string
+}
+
+func TestPlatformScheduling(t *testing.T) {
+	tests := []struct {
+		name     string
+		stations []string
+		routes   []string
+		packages []string
+		trains   []string
+		waits    []expectedWait
+		error    string
+	}{
+		{
+			name:     "a train waits for the platform of a train loading there",
+			stations: []string{"A", "B,platforms=1,handling=10", "C", "D"},
+			routes:   []string{"E1,A,B,10", "E2,B,C,20", "E3,D,B,5"},
+			packages: []string{"K1,5,B,C", "K2,5,D,B"},
+			trains:   []string{"Q1,5,A", "Q2,5,D"},
+			waits:    []expectedWait{{"Q2", "for a platform at station B"}},
+		},
+		{
+			name:     "a train passing through a station takes up a platform",
+			stations: []string{"A,platforms=1", "B", "C"},
+			routes:   []string{"E1,B,A,10", "E2,A,C,10"},
+			packages: []string{"K1,5,B,C"},
+			trains:   []string{"Q1,1,B,depot=A", "Q2,5,B"},
+			waits:    []expectedWait{{"Q1", "for a platform at station A"}},
+		},
+		{
+			name:     "an empty train blocked from a pickup is retried once the station is free",
+			stations: []string{"A", "B,platforms=1", "C"},
+			routes:   []string{"E1,A,B,10", "E2,B,C,10"},
+			packages: []string{"K1,1,B,C", "K2,3,B,C"},
+			trains:   []string{"Q0,5,A", "Q1,1,B"},
+		},
+		{
+			name:     "trains waiting on each other's platforms are reported as a deadlock",
+			stations: []string{"A,platforms=1", "B,platforms=1"},
+			routes:   []string{"E1,A,B,10"},
+			packages: []string{"K1,1,B,A", "K2,1,A,B"},
+			trains:   []string{"Q1,5,A", "Q2,5,B"},
+			error:    "deadlock: train Q1 waits for a platform at station B taken by train Q2, train Q2 waits for a platform at station A taken by train Q1",
+		},
+		{
+			name:     "a train parked for good in the way is reported",
+			stations: []string{"A", "B,platforms=1", "C"},
+			routes:   []string{"E1,A,B,10", "E2,B,C,10"},
+			packages: []string{"K1,5,C,A"},
+			trains:   []string{"Q1,5,A", "Q2,1,B"},
+			error:    "train Q1 cannot travel any further, every platform at station B is taken by trains that do not depart: Q2",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g, err := NewGraph(test.stations, test.routes, test.packages, test.trains, nil)
+			if err != nil {
+				t.Fatalf("unable to build the graph: %v", err)
+			}
+			g.BuildTravelTimeMatrix()
+
+			// a deadlock that is not detected would keep the planner looping, so it is given a time limit
+			done := make(chan error, 1)
+			go func() {
+				done <- g.Deliver()
+			}()
+			select {
+			case err = <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("planning did not finish, the trains might be waiting on each other forever")
+			}
+
+			if test.error != "" {
+				if err == nil || !strings.Contains(err.Error(), test.error) {
+					t.Fatalf("expected an error containing %q, found %v", test.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			dropped := make(map[PackageName]bool, 0)
+			for _, move := range g.Moves {
+				for _, delivery := range move.PackagesDropped {
+					dropped[delivery.Name] = true
+				}
+			}
+			for _, delivery := range g.Deliveries {
+				if !dropped[delivery.Name] {
+					t.Errorf("package %s was not delivered", delivery.Name)
+				}
+			}
+			for _, expected := range test.waits {
+				waited := false
+				for _, move := range g.Moves {
+					if move.Kind == WaitMove && move.Train.Name == expected.train && strings.Contains(move.Reason, expected.reason) {
+						waited = true
+					}
+				}
+				if !waited {
+					t.Errorf("expected train %s to wait %s", expected.train, expected.reason)
+				}
+			}
+			if conflicts := g.Conflicts(); len(conflicts) > 0 {
+				t.Errorf("expected no conflicts, found %+v", conflicts)
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package graph
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// rawEntry is an entry of the raw input split into its positional fields and the key=value attributes following them
+type rawEntry struct {
+	Fields     []string
+	Attributes map[string]rawAttribute
+}
+
+// rawAttribute is the value of a key=value attribute and the index of the comma separated field it was given in
+type rawAttribute struct {
+	Value string
+	Field int
+}
+
+/*
+splitFields splits an entry into its fields and attributes, reporting an issue if the number of fields does not match the section layout
+Attributes are reported if they are unknown, repeated, or followed by a positional field
+*/
+func (v *inputValidator) splitFields(section InputSection, index int, raw string) (rawEntry, bool) {
+	entry := rawEntry{
+		Fields:     make([]string, 0),
+		Attributes: make(map[string]rawAttribute, 0),
+	}
+	for i, field := range strings.Split(raw, ",") {
+		key, value, isAttribute := strings.Cut(field, "=")
+		if !isAttribute {
+			if len(entry.Attributes) > 0 {
+				v.report(section, index, i, "field %q must come before the key=value attributes", field)
+			}
+			entry.Fields = append(entry.Fields, field)
+			continue
+		}
+
+		key = strings.TrimSpace(key)
+		attributes := sectionAttributes[section]
+		_, repeated := entry.Attributes[key]
+		switch {
+		case !slices.Contains(attributes, key) && len(attributes) == 0:
+			v.reportAttribute(section, index, i, key, "unknown attribute %q, %s entries have no attributes", key, section)
+		case !slices.Contains(attributes, key):
+			v.reportAttribute(section, index, i, key, "unknown attribute %q, expected one of %s", key, strings.Join(attributes, ", "))
+		case repeated:
+			v.reportAttribute(section, index, i, key, "attribute %q is given more than once", key)
+		default:
+			entry.Attributes[key] = rawAttribute{Value: strings.TrimSpace(value), Field: i}
+		}
+	}
+
+	fields := entry.Fields
+	layout := sectionFields[section]
+	required := sectionRequiredFields[section]
+	if len(fields) < required || len(fields) > len(layout) {
+		if required < len(layout) {
+			v.report(section, index, -1, "expected %d to %d fields (%s[,%s]), found %d", required, len(layout), strings.Join(layout[:required], ","), strings.Join(layout[required:], ","), len(fields))
+		} else {
+			v.report(section, index, -1, "expected %d fields (%s), found %d", required, strings.Join(layout, ","), len(fields))
+		}
+		return entry, false
+	}
+	return entry, true
+}
+
+// parseWeight parses a weight or capacity field with an optional unit, reporting an issue if it is not valid
+func (v *inputValidator) parseWeight(section InputSection, index int, field int, what string, raw string) int {
+	value, err := ParseWeight(raw)
+	if err != nil {
+		v.report(section, index, field, "%s %v", what, err)
+	}
+	return value
+}
+
+// parseTravelTime parses a travel time field with an optional unit, reporting an issue if it is not valid
+func (v *inputValidator) parseTravelTime(section InputSection, index int, field int, raw string) int {
+	value, err := ParseTravelTime(raw)
+	if err != nil {
+		v.report(section, index, field, "travel time %v", err)
+	}
+	return value
+}
+
+// parseTimeAttribute parses an optional attribute holding a time in minutes with an optional unit, returning 0 if it is not given
+func (v *inputValidator) parseTimeAttribute(section InputSection, index int, entry rawEntry, key string, what string) int {
+	attribute, exists := entry.Attributes[key]
+	if !exists {
+		return 0
+	}
+	value, err := ParseTravelTime(attribute.Value)
+	if err != nil {
+		v.reportAttribute(section, index, attribute.Field, key, "%s %v", what, err)
+	}
+	return value
+}
+
+// parseCountAttribute parses an optional attribute holding a number of trains, returning 0 if it is not given
+func (v *inputValidator) parseCountAttribute(section InputSection, index int, entry rawEntry, key string, what string) int {
+	attribute, exists := entry.Attributes[key]
+	if !exists {
+		return 0
+	}
+	count, err := strconv.Atoi(attribute.Value)
+	if err != nil || count <= 0 {
+		v.reportAttribute(section, index, attribute.Field, key, "%s must be a number of trains greater than 0, found %q", what, attribute.Value)
+		return 0
+	}
+	return count
+}
+
+// parseSpeedAttribute parses an optional attribute holding a speed factor, returning 0 if it is not given
+func (v *inputValidator) parseSpeedAttribute(section InputSection, index int, entry rawEntry, key string, what string) float64 {
+	attribute, exists := entry.Attributes[key]
+	if !exists {
+		return 0
+	}
+	speed, err := ParseSpeed(attribute.Value)
+	if err != nil {
+		v.reportAttribute(section, index, attribute.Field, key, "%s %v", what, err)
+	} else if speed <= 0 {
+		v.reportAttribute(section, index, attribute.Field, key, "%s must be greater than 0, found %q", what, attribute.Value)
+	}
+	return speed
+}
+
+// parseVolumeAttribute parses the optional volume attribute of an entry, returning 0 if it is not given
+func (v *inputValidator) parseVolumeAttribute(section InputSection, index int, entry rawEntry, what string) int {
+	attribute, exists := entry.Attributes["volume"]
+	if !exists {
+		return 0
+	}
+	volume, err := ParseVolume(attribute.Value)
+	if err != nil {
+		v.reportAttribute(section, index, attribute.Field, "volume", "%s %v", what, err)
+	} else if volume <= 0 {
+		v.reportAttribute(section, index, attribute.Field, "volume", "%s must be greater than 0, found %q", what, attribute.Value)
+	}
+	return volume
+}
+
+// parseHandlingRate parses the optional handling rate attribute of a station, returning 0 if it is not given
+func (v *inputValidator) parseHandlingRate(index int, entry rawEntry) float64 {
+	attribute, exists := entry.Attributes["handling-rate"]
+	if !exists {
+		return 0
+	}
+	rate, err := ParseHandlingRate(attribute.Value)
+	if err != nil {
+		v.reportAttribute(StationSection, index, attribute.Field, "handling-rate", "handling rate %v", err)
+	}
+	return rate
+}
+
+// fieldAt returns the field at the given index, or an empty string for malformed entries with less fields
+func fieldAt(fields []string, index int) string {
+	if index < len(fields) {
+		return fields[index]
+	}
+	return ""
+}
+
+/*
+ParseProblem converts the raw input strings accepted by NewGraph to a Problem, in the formats:
+Station: A or A,handling=5,handling-rate=2m/t for a station taking 5 minutes plus 2 minutes per tonne to load or unload packages
+or A,platforms=2 for a station that can only hold 2 trains at once
+Route: E1,A,B,10 or E1,A,B,10,-> for a one-way route
+or E1,A,B,10,capacity=1,track=single for a single-track route that only one train can travel at a time
+or E1,A,B,10,max-speed=1 for a route that faster trains can only travel in its given travel time
+or E1,A,B,10,max-load=2t for a route that trains carrying more than 2 tonnes of packages may not use
+or E1,A,B,10,closed=1h-2h;5h-6h for a route closed between 1 and 2 hours and between 5 and 6 hours into the plan
+Package: K1,3,A,E or K1,3,A,E,ready=30,due=2h for a package released after 30 minutes and due within 2 hours
+or K1,3,A,E,priority=express for an express package, the classes are express, standard (the default) and economy
+Train: Q1,3,A or Q1,3,A,coupling=10 for a train taking 10 minutes to couple or uncouple wagons whenever it loads or unloads packages
+or Q1,3,A,speed=1.5 for a train travelling the routes 1.5 times as fast, or speed=0.5 for one taking twice as long
+or Q1,3,A,depot=D for a train returning to station D once every package is delivered
+or Q1,3,A,duty=4h,rest=45m,shift=8h for a crew taking a 45 minute break after 4 hours of work and working 8 hours at most
+Packages and trains can also have a volume, e.g. K1,3,A,E,volume=500l and Q1,3t,A,volume=20m3, trains without one are only limited by weight
+or K1,3,A,E,category=hazmat for a package of a category, see Incompatibility
+Incompatibility: hazmat,food for packages of the 2 categories that must not be carried by the same train at the same time
+Weights and capacities are in kilograms, volumes in litres and times in minutes, unless a unit is given, e.g. 2.5t, 500kg, 2m3, 1h30m
+Optional key=value attributes follow the fields of an entry, see sectionAttributes
+Every malformed entry is reported, the returned Problem keeps one entry per raw string so issues can be traced back to it
+*/
+func ParseProblem(rawStations []string, rawRoutes []string, rawDeliveries []string, rawTrains []string, rawIncompatibilities []string) (Problem, []InputIssue) {
+	v := newInputValidator()
+	problem := Problem{
+		Stations: make([]StationSpec, 0, len(rawStations)),
+		Routes:   make([]RouteSpec, 0, len(rawRoutes)),
+		Packages: make([]PackageSpec, 0, len(rawDeliveries)),
+		Trains:   make([]TrainSpec, 0, len(rawTrains)),
+
+		Incompatibilities: make([]IncompatibilitySpec, 0, len(rawIncompatibilities)),
+	}
+
+	for i, rawStation := range rawStations {
+		entry, _ := v.splitFields(StationSection, i, rawStation)
+		problem.Stations = append(problem.Stations, StationSpec{
+			Name:         fieldAt(entry.Fields, 0),
+			HandlingTime: v.parseTimeAttribute(StationSection, i, entry, "handling", "handling time"),
+			HandlingRate: v.parseHandlingRate(i, entry),
+			Platforms:    v.parseCountAttribute(StationSection, i, entry, "platforms", "number of platforms"),
+		})
+	}
+
+	for i, rawRoute := range rawRoutes {
+		entry, ok := v.splitFields(RouteSection, i, rawRoute)
+		route := entry.Fields
+		routeSpec := RouteSpec{
+			Name: fieldAt(route, 0),
+			From: fieldAt(route, 1),
+			To:   fieldAt(route, 2),
+		}
+		if ok {
+			routeSpec.TravelTime = v.parseTravelTime(RouteSection, i, 3, route[3])
+			if len(route) > 4 {
+				switch route[4] {
+				case OneWayMarker:
+					routeSpec.OneWay = true
+				case TwoWayMarker:
+				default:
+					v.report(RouteSection, i, 4, "direction must be %s or %s, found %q", OneWayMarker, TwoWayMarker, route[4])
+				}
+			}
+		}
+		routeSpec.Capacity = v.parseCountAttribute(RouteSection, i, entry, "capacity", "capacity")
+		routeSpec.MaxSpeed = v.parseSpeedAttribute(RouteSection, i, entry, "max-speed", "maximum speed factor")
+		if attribute, exists := entry.Attributes["max-load"]; exists {
+			maxLoad, err := ParseWeight(attribute.Value)
+			if err != nil {
+				v.reportAttribute(RouteSection, i, attribute.Field, "max-load", "maximum load %v", err)
+			} else if maxLoad <= 0 {
+				v.reportAttribute(RouteSection, i, attribute.Field, "max-load", "maximum load must be greater than 0, found %q", attribute.Value)
+			}
+			routeSpec.MaxLoad = maxLoad
+		}
+		if attribute, exists := entry.Attributes["closed"]; exists {
+			closures, err := ParseClosures(attribute.Value)
+			if err != nil {
+				v.reportAttribute(RouteSection, i, attribute.Field, "closed", "closure %v", err)
+			}
+			routeSpec.Closures = closures
+		}
+		if attribute, exists := entry.Attributes["track"]; exists {
+			switch attribute.Value {
+			case SingleTrack:
+				routeSpec.SingleTrack = true
+			case DoubleTrack:
+			default:
+				v.reportAttribute(RouteSection, i, attribute.Field, "track", "track must be %s or %s, found %q", SingleTrack, DoubleTrack, attribute.Value)
+			}
+		}
+		problem.Routes = append(problem.Routes, routeSpec)
+	}
+
+	for i, rawDelivery := range rawDeliveries {
+		entry, ok := v.splitFields(PackageSection, i, rawDelivery)
+		delivery := entry.Fields
+		packageSpec := PackageSpec{
+			Name:      fieldAt(delivery, 0),
+			From:      fieldAt(delivery, 2),
+			To:        fieldAt(delivery, 3),
+			ReadyTime: v.parseTimeAttribute(PackageSection, i, entry, "ready", "ready time"),
+			DueTime:   v.parseTimeAttribute(PackageSection, i, entry, "due", "due time"),
+			Volume:    v.parseVolumeAttribute(PackageSection, i, entry, "volume"),
+		}
+		if attribute, exists := entry.Attributes["category"]; exists {
+			packageSpec.Category = attribute.Value
+		}
+		if ok {
+			packageSpec.Weight = v.parseWeight(PackageSection, i, 1, "weight", delivery[1])
+		}
+		if attribute, exists := entry.Attributes["priority"]; exists {
+			priority, err := ParsePriorityClass(attribute.Value)
+			if err != nil {
+				v.reportAttribute(PackageSection, i, attribute.Field, "priority", "priority %v", err)
+			}
+			packageSpec.Priority = priority
+		}
+		// a due time of 0 is how packages without a deadline are represented, so it cannot be given explicitly
+		if attribute, exists := entry.Attributes["due"]; exists {
+			if dueTime, err := ParseTravelTime(attribute.Value); err == nil && dueTime == 0 {
+				v.reportAttribute(PackageSection, i, attribute.Field, "due", "due time must be greater than 0, leave it out for packages without a deadline")
+			}
+		}
+		problem.Packages = append(problem.Packages, packageSpec)
+	}
+
+	for i, rawTrain := range rawTrains {
+		entry, ok := v.splitFields(TrainSection, i, rawTrain)
+		train := entry.Fields
+		trainSpec := TrainSpec{
+			Name:         fieldAt(train, 0),
+			Start:        fieldAt(train, 2),
+			CouplingTime: v.parseTimeAttribute(TrainSection, i, entry, "coupling", "coupling time"),
+			Speed:        v.parseSpeedAttribute(TrainSection, i, entry, "speed", "speed factor"),
+			Volume:       v.parseVolumeAttribute(TrainSection, i, entry, "volume capacity"),
+			DutyLimit:    v.parseTimeAttribute(TrainSection, i, entry, "duty", "duty limit"),
+			ShiftLimit:   v.parseTimeAttribute(TrainSection, i, entry, "shift", "shift limit"),
+			RestTime:     v.parseTimeAttribute(TrainSection, i, entry, "rest", "rest time"),
+		}
+		if attribute, exists := entry.Attributes["depot"]; exists {
+			if attribute.Value == "" {
+				v.reportAttribute(TrainSection, i, attribute.Field, "depot", "depot station is empty, leave it out for trains staying where they end up")
+			}
+			trainSpec.Depot = attribute.Value
+		}
+		if ok {
+			trainSpec.Capacity = v.parseWeight(TrainSection, i, 1, "capacity", train[1])
+		}
+		problem.Trains = append(problem.Trains, trainSpec)
+	}
+
+	for i, rawIncompatibility := range rawIncompatibilities {
+		entry, _ := v.splitFields(IncompatibilitySection, i, rawIncompatibility)
+		problem.Incompatibilities = append(problem.Incompatibilities, IncompatibilitySpec{
+			Category:      strings.TrimSpace(fieldAt(entry.Fields, 0)),
+			OtherCategory: strings.TrimSpace(fieldAt(entry.Fields, 1)),
+		})
+	}
+
+	return problem, v.issues
+}
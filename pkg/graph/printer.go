@@ -2,6 +2,7 @@ package graph
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"slices"
 	"strings"
@@ -13,6 +14,9 @@ type Printer struct {
 	Moves            []Move
 	StationNames     map[StationId]StationName
 	TravelTimeMatrix map[StationId]map[StationId]int
+	ShowHopDetails   bool // include the route, departure, arrival and duration of each move
+	ShowClock        bool // include wall clock times, counted from StartClock
+	StartClock       int  // minutes after midnight the plan starts at
 }
 
 func NewPrinter(moves []Move, stationNames map[StationId]string, travelTimeMatrix map[StationId]map[StationId]int) *Printer {
@@ -23,13 +27,31 @@ func NewPrinter(moves []Move, stationNames map[StationId]string, travelTimeMatri
 	}
 }
 
+// clockSuffix returns the wall clock time of a time in minutes to show next to it, e.g. " (06:30)", or nothing if ShowClock is disabled
+func (printer *Printer) clockSuffix(minutes int) string {
+	if !printer.ShowClock {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", FormatClock(printer.StartClock, minutes))
+}
+
 // Prints out the list of moves as specified by assignment requirements in the format of:
 // W=0, T=Q1, N1=B, P1=[], N2=A, P2=[]
+// If ShowHopDetails is enabled, the route and times of each move are appended:
+// W=0, T=Q1, N1=B, P1=[], N2=A, P2=[], R=E1, DEP=0, ARR=30, DUR=30
+// with R=- for moves staying at the station to pick up or drop off packages
+// followed by DETOUR=E2 for moves taking a detour around a closed route
+// If ShowClock is enabled, the wall clock time of each move is appended:
+// W=0, T=Q1, N1=B, P1=[], N2=A, P2=[], CLOCK=06:00
 func (printer *Printer) PrintMoves() {
 	slices.SortStableFunc(printer.Moves, func(a Move, b Move) int {
 		return strings.Compare(a.Train.Name, b.Train.Name)
 	})
 	for _, move := range printer.Moves {
+		// dwells and waits are not part of the assignment format, they show up as later departure times instead
+		if move.Kind != TravelMove {
+			continue
+		}
 		packagesCarriedNames := make([]string, 0)
 		for _, packageCarried := range move.PackagesCarried {
 			packagesCarriedNames = append(packagesCarriedNames, packageCarried.Name)
@@ -42,23 +64,75 @@ func (printer *Printer) PrintMoves() {
 		}
 		packageDroppedStr := fmt.Sprintf("[%s]", strings.Join(packageDroppedNames, ","))
 
-		fmt.Printf("W=%d, T=%s, N1=%s, P1=%s, N2=%s, P2=%s\n", move.TimeTaken, move.Train.Name, move.StartingStation.Name, packageCarriedStr, move.EndingStation.Name, packageDroppedStr)
+		fmt.Printf("W=%d, T=%s, N1=%s, P1=%s, N2=%s, P2=%s", move.TimeTaken, move.Train.Name, move.StartingStation.Name, packageCarriedStr, move.EndingStation.Name, packageDroppedStr)
+		if printer.ShowHopDetails {
+			routeName := move.RouteName
+			if routeName == "" {
+				// the train stays at the station to pick up or drop off packages, so there is no route to show
+				routeName = "-"
+			}
+			fmt.Printf(", R=%s, DEP=%d, ARR=%d, DUR=%d", routeName, move.DepartureTime, move.ArrivalTime, move.Duration)
+			if move.DetourAround != "" {
+				fmt.Printf(", DETOUR=%s", move.DetourAround)
+			}
+		}
+		if printer.ShowClock {
+			fmt.Printf(", CLOCK=%s", FormatClock(printer.StartClock, move.TimeTaken))
+		}
+		fmt.Println()
 	}
 	fmt.Println()
 }
 
 // Prints out the list of moves each train takes in a more detailed format
+// Periods a train spends dwelling or waiting at a station, and rest breaks of its crew, are shown as their own entries
 func (printer *Printer) PrintMovesVerbose() {
 	// sort by train to easily track moves per train
 	slices.SortStableFunc(printer.Moves, func(a Move, b Move) int {
 		return strings.Compare(a.Train.Name, b.Train.Name)
 	})
 	for _, move := range printer.Moves {
-		fmt.Printf("[%d minutes] Train %s moving from station %s to station %s\n", move.TimeTaken, move.Train.Name, move.StartingStation.Name, move.EndingStation.Name)
+		switch move.Kind {
+		case DwellMove:
+			fmt.Printf("[%d minutes%s] Train %s dwells at station %s for %d minutes, %s\n\n", move.TimeTaken, printer.clockSuffix(move.TimeTaken), move.Train.Name, move.StartingStation.Name, move.Duration, move.Reason)
+			continue
+		case WaitMove:
+			fmt.Printf("[%d minutes%s] Train %s waits at station %s for %d minutes %s\n\n", move.TimeTaken, printer.clockSuffix(move.TimeTaken), move.Train.Name, move.StartingStation.Name, move.Duration, move.Reason)
+			continue
+		case RestMove:
+			fmt.Printf("[%d minutes%s] Crew of train %s takes a %d minute rest break at station %s %s\n\n", move.TimeTaken, printer.clockSuffix(move.TimeTaken), move.Train.Name, move.Duration, move.StartingStation.Name, move.Reason)
+			continue
+		}
+		if move.RouteName != "" {
+			fmt.Printf("[%d minutes%s] Train %s moving from station %s to station %s via route %s\n", move.TimeTaken, printer.clockSuffix(move.TimeTaken), move.Train.Name, move.StartingStation.Name, move.EndingStation.Name, move.RouteName)
+		} else {
+			fmt.Printf("[%d minutes%s] Train %s moving from station %s to station %s\n", move.TimeTaken, printer.clockSuffix(move.TimeTaken), move.Train.Name, move.StartingStation.Name, move.EndingStation.Name)
+		}
+		if printer.ShowHopDetails {
+			fmt.Printf("Departs at %d minutes%s and arrives at %d minutes%s, taking %d minutes\n", move.DepartureTime, printer.clockSuffix(move.DepartureTime), move.ArrivalTime, printer.clockSuffix(move.ArrivalTime), move.Duration)
+		}
+		if move.DetourAround != "" {
+			fmt.Printf("Detour around route %s, which is closed\n", move.DetourAround)
+		}
+		if move.ReturnLeg {
+			fmt.Printf("Returning to its depot at station %s\n", printer.StationNames[move.Train.DepotStationId])
+		}
 		if len(move.PackagesCarried) > 0 {
 			fmt.Println("Carried packages:")
 			for _, carriedPackage := range move.PackagesCarried {
-				fmt.Printf("	- %s package with weight %d heading to %s station\n", carriedPackage.Name, carriedPackage.Weight, printer.StationNames[carriedPackage.EndingStationId])
+				fmt.Printf("	- %s package", carriedPackage.Name)
+				if carriedPackage.Category != "" {
+					fmt.Printf(" (%s)", carriedPackage.Category)
+				}
+				fmt.Printf(" with weight %d", carriedPackage.Weight)
+				if carriedPackage.Volume > 0 {
+					fmt.Printf(" and volume %dl", carriedPackage.Volume)
+				}
+				fmt.Printf(" heading to %s station", printer.StationNames[carriedPackage.EndingStationId])
+				if carriedPackage.HasDeadline() {
+					fmt.Printf(", due at %d minutes%s", carriedPackage.DueTime, printer.clockSuffix(carriedPackage.DueTime))
+				}
+				fmt.Println()
 			}
 		}
 		if len(move.PackagesDropped) > 0 {
@@ -73,25 +147,225 @@ func (printer *Printer) PrintMovesVerbose() {
 }
 
 // Prints an overall summary for each package's delivery time as well as which train delivered it
+// If any package has a deadline, its due time and how late it was delivered are included
+// If any package is not of the standard priority class, delivery statistics per class are included
+// The summary ends with how much of its weight and volume capacity each train used, and when the trains with a depot got back to it
 func (printer *Printer) PrintSummary() {
 	// sort by train to easily track moves per train
 	slices.SortStableFunc(printer.Moves, func(a Move, b Move) int {
 		return strings.Compare(a.Train.Name, b.Train.Name)
 	})
 	movesWithDeliveredPackages := make([]Move, 0)
+	hasDeadlines := false
 	for _, move := range printer.Moves {
 		if len(move.PackagesDropped) > 0 {
 			movesWithDeliveredPackages = append(movesWithDeliveredPackages, move)
 		}
+		for _, deliveredPackage := range move.PackagesDropped {
+			hasDeadlines = hasDeadlines || deliveredPackage.HasDeadline()
+		}
 	}
 	w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
-	fmt.Fprintln(w, "Name\tWeight\tDeliveredAt\tTrain\t")
+	if hasDeadlines {
+		fmt.Fprintln(w, "Name\tWeight\tDeliveredAt\tTrain\tDue\tLate\t")
+	} else {
+		fmt.Fprintln(w, "Name\tWeight\tDeliveredAt\tTrain\t")
+	}
+
+	packagesWithDeadline, latePackages, totalLateness := 0, 0, 0
+	for _, move := range movesWithDeliveredPackages {
+		for _, deliveredPackage := range move.PackagesDropped {
+			fmt.Fprintf(w, "%s\t%dkg\t%dm%s\t%s\t", deliveredPackage.Name, deliveredPackage.Weight, move.ArrivalTime, printer.clockSuffix(move.ArrivalTime), move.Train.Name)
+			if hasDeadlines {
+				printer.printLateness(w, deliveredPackage, move.ArrivalTime)
+				if deliveredPackage.HasDeadline() {
+					packagesWithDeadline++
+				}
+				if lateness := deliveredPackage.Lateness(move.ArrivalTime); lateness > 0 {
+					latePackages++
+					totalLateness += lateness
+				}
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	w.Flush()
+	if hasDeadlines {
+		fmt.Printf("\n%d of %d packages with a deadline delivered late, %d minutes late in total\n", latePackages, packagesWithDeadline, totalLateness)
+	}
+	printer.printClassSummary(movesWithDeliveredPackages, hasDeadlines)
+	printer.printUtilisation()
+	printer.printDepotReturns()
+}
 
+// printLateness prints the due time of a delivered package and how late it was delivered as summary columns
+func (printer *Printer) printLateness(w io.Writer, deliveredPackage Package, deliveredAt int) {
+	if !deliveredPackage.HasDeadline() {
+		fmt.Fprint(w, "-\t-\t")
+		return
+	}
+	fmt.Fprintf(w, "%dm%s\t", deliveredPackage.DueTime, printer.clockSuffix(deliveredPackage.DueTime))
+	if lateness := deliveredPackage.Lateness(deliveredAt); lateness > 0 {
+		fmt.Fprintf(w, "%dm\t", lateness)
+	} else {
+		fmt.Fprint(w, "on time\t")
+	}
+}
+
+// printClassSummary prints the number of packages delivered per priority class and how long they took from being ready to being delivered
+// Nothing is printed if every package is of the standard class
+func (printer *Printer) printClassSummary(movesWithDeliveredPackages []Move, hasDeadlines bool) {
+	type classStats struct {
+		delivered         int
+		totalDeliveryTime int
+		maxDeliveryTime   int
+		late              int
+	}
+	stats := make(map[PriorityClass]*classStats, 0)
+	for _, class := range priorityClasses {
+		stats[class] = &classStats{}
+	}
+	hasClasses := false
 	for _, move := range movesWithDeliveredPackages {
 		for _, deliveredPackage := range move.PackagesDropped {
-			travelTime := printer.TravelTimeMatrix[move.StartingStation.Id][move.EndingStation.Id]
-			fmt.Fprintf(w, "%s\t%dkg\t%dm\t%s\t\n", deliveredPackage.Name, deliveredPackage.Weight, move.TimeTaken+travelTime, move.Train.Name)
+			hasClasses = hasClasses || deliveredPackage.Priority != StandardPriority
+			stat := stats[deliveredPackage.Priority]
+			deliveryTime := move.ArrivalTime - deliveredPackage.ReadyTime
+			stat.delivered++
+			stat.totalDeliveryTime += deliveryTime
+			stat.maxDeliveryTime = max(stat.maxDeliveryTime, deliveryTime)
+			if deliveredPackage.Lateness(move.ArrivalTime) > 0 {
+				stat.late++
+			}
+		}
+	}
+	if !hasClasses {
+		return
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
+	if hasDeadlines {
+		fmt.Fprintln(w, "Class\tDelivered\tAvgDeliveryTime\tMaxDeliveryTime\tLate\t")
+	} else {
+		fmt.Fprintln(w, "Class\tDelivered\tAvgDeliveryTime\tMaxDeliveryTime\t")
+	}
+	for _, class := range priorityClasses {
+		stat := stats[class]
+		if stat.delivered == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%d\t%dm\t%dm\t", class, stat.delivered, stat.totalDeliveryTime/stat.delivered, stat.maxDeliveryTime)
+		if hasDeadlines {
+			fmt.Fprintf(w, "%d\t", stat.late)
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+}
+
+// percentage returns how much of the total the used amount is, rounded to the nearest whole percent, e.g. 2 of 3 is 67%
+func percentage(used int, total int) int {
+	return (100*used + total/2) / total
+}
+
+/*
+printUtilisation prints the heaviest load each train carried at once and how much of its capacity that used up
+The volume is included if any package or train has one, a train without a volume capacity is only limited by weight
+*/
+func (printer *Printer) printUtilisation() {
+	type trainStats struct {
+		capacity       int
+		volumeCapacity int
+		peakLoad       int
+		peakVolume     int
+	}
+	stats := make(map[string]*trainStats, 0)
+	trainNames := make([]string, 0)
+	hasVolumes := false
+	for _, move := range printer.Moves {
+		if move.Kind != TravelMove {
+			continue
+		}
+		stat, exists := stats[move.Train.Name]
+		if !exists {
+			stat = &trainStats{capacity: move.Train.TotalCapacity(), volumeCapacity: move.Train.VolumeCapacity}
+			stats[move.Train.Name] = stat
+			trainNames = append(trainNames, move.Train.Name)
+		}
+		// the packages dropped off at the end of a move were still on board while travelling it
+		load, volume := 0, 0
+		for _, carriedPackage := range slices.Concat(move.PackagesCarried, move.PackagesDropped) {
+			load += carriedPackage.Weight
+			volume += carriedPackage.Volume
+		}
+		stat.peakLoad = max(stat.peakLoad, load)
+		stat.peakVolume = max(stat.peakVolume, volume)
+		hasVolumes = hasVolumes || volume > 0 || move.Train.VolumeCapacity > 0
+	}
+	slices.Sort(trainNames)
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
+	if hasVolumes {
+		fmt.Fprintln(w, "Train\tPeakLoad\tWeightUsed\tPeakVolume\tVolumeUsed\t")
+	} else {
+		fmt.Fprintln(w, "Train\tPeakLoad\tWeightUsed\t")
+	}
+	for _, trainName := range trainNames {
+		stat := stats[trainName]
+		fmt.Fprintf(w, "%s\t%dkg/%dkg\t%d%%\t", trainName, stat.peakLoad, stat.capacity, percentage(stat.peakLoad, stat.capacity))
+		if hasVolumes {
+			if stat.volumeCapacity > 0 {
+				fmt.Fprintf(w, "%dl/%dl\t%d%%\t", stat.peakVolume, stat.volumeCapacity, percentage(stat.peakVolume, stat.volumeCapacity))
+			} else {
+				fmt.Fprintf(w, "%dl\t-\t", stat.peakVolume)
+			}
 		}
+		fmt.Fprintln(w)
 	}
 	w.Flush()
 }
+
+// printDepotReturns prints the time each train with a depot finished its day back at the depot
+// Nothing is printed if no train has a depot
+func (printer *Printer) printDepotReturns() {
+	returnedAt := make(map[string]int, 0)
+	depots := make(map[string]StationId, 0)
+	trainNames := make([]string, 0)
+	for _, move := range printer.Moves {
+		if !move.Train.HasDepot() {
+			continue
+		}
+		if _, exists := depots[move.Train.Name]; !exists {
+			depots[move.Train.Name] = move.Train.DepotStationId
+			trainNames = append(trainNames, move.Train.Name)
+		}
+		returnedAt[move.Train.Name] = max(returnedAt[move.Train.Name], move.ArrivalTime)
+	}
+	if len(trainNames) == 0 {
+		return
+	}
+	slices.Sort(trainNames)
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
+	fmt.Fprintln(w, "Train\tDepot\tReturnedAt\t")
+	for _, trainName := range trainNames {
+		fmt.Fprintf(w, "%s\t%s\t%dm%s\t\n", trainName, printer.StationNames[depots[trainName]], returnedAt[trainName], printer.clockSuffix(returnedAt[trainName]))
+	}
+	w.Flush()
+}
+
+// Prints the route and platform conflicts found in the plan, see Graph.Conflicts
+func (printer *Printer) PrintConflicts(conflicts []Conflict) {
+	fmt.Println()
+	if len(conflicts) == 0 {
+		fmt.Println("No conflicts found")
+		return
+	}
+	fmt.Printf("Found %d conflicts:\n", len(conflicts))
+	for _, conflict := range conflicts {
+		fmt.Printf("	- %s at %d minutes%s: trains %s %s\n", conflict.Location(), conflict.Time, printer.clockSuffix(conflict.Time), strings.Join(conflict.Trains, ", "), conflict.Reason)
+	}
+}
@@ -0,0 +1,60 @@
+package graph
+
+import (
+	"fmt"
+)
+
+// PriorityClass is the delivery class of a package, express packages are picked up before standard ones and standard before economy
+type PriorityClass int
+
+const (
+	StandardPriority PriorityClass = iota // the default class of a package
+	ExpressPriority
+	EconomyPriority
+)
+
+// priorityClasses lists the priority classes from the most to the least urgent
+var priorityClasses = []PriorityClass{ExpressPriority, StandardPriority, EconomyPriority}
+
+// StrictPriority is the priority weight of a Graph that always picks up packages of a higher class first, however far away they are
+const StrictPriority = -1
+
+func (class PriorityClass) String() string {
+	switch class {
+	case ExpressPriority:
+		return "express"
+	case StandardPriority:
+		return "standard"
+	case EconomyPriority:
+		return "economy"
+	default:
+		return fmt.Sprintf("PriorityClass(%d)", int(class))
+	}
+}
+
+// Rank returns how urgent the class is, from 0 for economy up to 2 for express packages
+func (class PriorityClass) Rank() int {
+	switch class {
+	case ExpressPriority:
+		return 2
+	case StandardPriority:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// IsValid checks if the class is one of the defined priority classes
+func (class PriorityClass) IsValid() bool {
+	return class >= StandardPriority && class <= EconomyPriority
+}
+
+// ParsePriorityClass parses the name of a priority class, e.g. express
+func ParsePriorityClass(raw string) (PriorityClass, error) {
+	for _, class := range priorityClasses {
+		if raw == class.String() {
+			return class, nil
+		}
+	}
+	return StandardPriority, fmt.Errorf("%q is not a priority class, expected express, standard or economy", raw)
+}
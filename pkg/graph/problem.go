@@ -0,0 +1,226 @@
+package graph
+
+import (
+	"errors"
+)
+
+// StationSpec describes a station of the network
+type StationSpec struct {
+	Name         StationName
+	HandlingTime int     // minutes taken to load or unload packages at the station, regardless of their weight
+	HandlingRate float64 // additional minutes taken per kilogram of packages loaded or unloaded
+	Platforms    int     // number of trains the station can hold at once, 0 if unlimited
+}
+
+// RouteSpec describes a route between 2 stations, routes are bidirectional unless OneWay is set
+type RouteSpec struct {
+	Name        string
+	From        StationName
+	To          StationName
+	TravelTime  int
+	OneWay      bool      // the route can only be travelled from From to To
+	Capacity    int       // number of trains allowed on the route at the same time in each direction, 0 if unlimited
+	SingleTrack bool      // trains cannot travel the route in opposite directions at the same time
+	MaxSpeed    float64   // highest speed factor trains can travel the route at, 0 if unlimited
+	MaxLoad     int       // heaviest load in kilograms a train may carry on the route, 0 if unlimited
+	Closures    []Closure // periods the route cannot be travelled in, e.g. for engineering works
+}
+
+// PackageSpec describes a package to be delivered from a station to another
+type PackageSpec struct {
+	Name      PackageName
+	Weight    int
+	From      StationName
+	To        StationName
+	ReadyTime int // time the package can be picked up from, 0 if it is available from the start
+	DueTime   int // time the package should be delivered by, 0 if it has no deadline
+	Priority  PriorityClass
+	Volume    int    // volume in litres, 0 if it is not known
+	Category  string // category such as hazmat or food that decides which packages it can be carried with, empty if it has none
+}
+
+// IncompatibilitySpec describes 2 categories of packages that must not be carried by a train at the same time
+// Both categories can be the same, in which case packages of that category must each be carried on their own
+type IncompatibilitySpec struct {
+	Category      string
+	OtherCategory string
+}
+
+// TrainSpec describes a train and the station it starts at
+type TrainSpec struct {
+	Name         string
+	Capacity     int
+	Start        StationName
+	CouplingTime int         // minutes taken to couple or uncouple wagons whenever packages are loaded or unloaded
+	Speed        float64     // factor the travel times of the routes are divided by, 0 for the NormalSpeed
+	Volume       int         // volume in litres of packages the train can hold, 0 if unlimited
+	Depot        StationName // station the train returns to once every package is delivered, empty if it stays where it ends up
+	DutyLimit    int         // minutes the crew can work continuously before a rest break, 0 if unlimited
+	ShiftLimit   int         // minutes the crew can work in total during its shift, 0 if unlimited
+	RestTime     int         // minutes a rest break lasts, 0 for the DefaultRestTime
+}
+
+// Problem describes the stations, routes, packages and trains to plan deliveries for
+// It allows building a Graph programmatically without formatting the raw input strings accepted by NewGraph
+type Problem struct {
+	Stations []StationSpec
+	Routes   []RouteSpec
+	Packages []PackageSpec
+	Trains   []TrainSpec
+
+	Incompatibilities []IncompatibilitySpec
+}
+
+// joinIssues combines a list of input issues into a single error
+func joinIssues(issues []InputIssue) error {
+	errs := make([]error, 0, len(issues))
+	for _, issue := range issues {
+		errs = append(errs, issue)
+	}
+	return errors.Join(errs...)
+}
+
+// NewGraphFromProblem creates a new Graph instance from a typed problem definition
+// The problem is validated first and every issue found is returned as a joined error
+func NewGraphFromProblem(problem Problem) (*Graph, error) {
+	if issues := ValidateProblem(problem); len(issues) > 0 {
+		return nil, joinIssues(issues)
+	}
+
+	stations := make(map[int]*Station, 0)
+	stationNamesToIdMap := make(map[string]int, 0)
+	stationNamesMap := make(map[int]string, 0)
+	for i, stationSpec := range problem.Stations {
+		stations[i] = &Station{
+			Id:              i,
+			Name:            stationSpec.Name,
+			InitialPackages: make(map[PackageName]*Package, 0),
+			HandlingTime:    stationSpec.HandlingTime,
+			HandlingRate:    stationSpec.HandlingRate,
+			Platforms:       stationSpec.Platforms,
+		}
+		stationNamesToIdMap[stationSpec.Name] = i
+		stationNamesMap[i] = stationSpec.Name
+	}
+
+	routes := make(map[int]map[int][]*Route, 0)
+	for _, routeSpec := range problem.Routes {
+		fromStation := stationNamesToIdMap[routeSpec.From]
+		toStation := stationNamesToIdMap[routeSpec.To]
+
+		if _, exists := routes[fromStation]; !exists {
+			routes[fromStation] = make(map[int][]*Route, 0)
+		}
+		if _, exists := routes[toStation]; !exists {
+			routes[toStation] = make(map[int][]*Route, 0)
+		}
+
+		// parallel routes between the same stations are all kept, the fastest one is chosen for each hop
+		routes[fromStation][toStation] = append(routes[fromStation][toStation], &Route{
+			Name:        routeSpec.Name,
+			TravelTime:  routeSpec.TravelTime,
+			OneWay:      routeSpec.OneWay,
+			Capacity:    routeSpec.Capacity,
+			SingleTrack: routeSpec.SingleTrack,
+			MaxSpeed:    routeSpec.MaxSpeed,
+			MaxLoad:     routeSpec.MaxLoad,
+			Closures:    routeSpec.Closures,
+		})
+		// bidirectional unless marked as one-way
+		if !routeSpec.OneWay {
+			routes[toStation][fromStation] = append(routes[toStation][fromStation], &Route{
+				Name:        routeSpec.Name,
+				TravelTime:  routeSpec.TravelTime,
+				Capacity:    routeSpec.Capacity,
+				SingleTrack: routeSpec.SingleTrack,
+				MaxSpeed:    routeSpec.MaxSpeed,
+				MaxLoad:     routeSpec.MaxLoad,
+				Closures:    routeSpec.Closures,
+			})
+		}
+	}
+
+	deliveries := make([]Package, 0)
+	for _, packageSpec := range problem.Packages {
+		fromStationId := stationNamesToIdMap[packageSpec.From]
+		toStationId := stationNamesToIdMap[packageSpec.To]
+
+		newDelivery := Package{
+			Name:              packageSpec.Name,
+			Weight:            packageSpec.Weight,
+			StartingStationId: fromStationId,
+			EndingStationId:   toStationId,
+			ReadyTime:         packageSpec.ReadyTime,
+			DueTime:           packageSpec.DueTime,
+			Priority:          packageSpec.Priority,
+			Volume:            packageSpec.Volume,
+			Category:          packageSpec.Category,
+		}
+
+		// keep track of which stations is initially holding the packages
+		stations[fromStationId].InitialPackages[newDelivery.Name] = &newDelivery
+
+		deliveries = append(deliveries, newDelivery)
+	}
+
+	trains := make(map[string]*Train, 0)
+	for _, trainSpec := range problem.Trains {
+		speed := trainSpec.Speed
+		if speed == 0 {
+			speed = NormalSpeed
+		}
+		restTime := trainSpec.RestTime
+		if restTime == 0 {
+			restTime = DefaultRestTime
+		}
+		depotStationId := NoDepot
+		if trainSpec.Depot != "" {
+			depotStationId = stationNamesToIdMap[trainSpec.Depot]
+		}
+		trains[trainSpec.Name] = &Train{
+			Name:             trainSpec.Name,
+			Capacity:         trainSpec.Capacity,
+			CurrentStationId: stationNamesToIdMap[trainSpec.Start],
+			PackagesCarried:  make([]Package, 0),
+			CouplingTime:     trainSpec.CouplingTime,
+			Speed:            speed,
+			VolumeCapacity:   trainSpec.Volume,
+			DepotStationId:   depotStationId,
+			DutyLimit:        trainSpec.DutyLimit,
+			ShiftLimit:       trainSpec.ShiftLimit,
+			RestTime:         restTime,
+		}
+	}
+
+	incompatible := make(map[string]map[string]bool, 0)
+	for _, incompatibilitySpec := range problem.Incompatibilities {
+		// incompatibility goes both ways, so the pair is stored in both orders
+		for _, pair := range [][2]string{{incompatibilitySpec.Category, incompatibilitySpec.OtherCategory}, {incompatibilitySpec.OtherCategory, incompatibilitySpec.Category}} {
+			if _, exists := incompatible[pair[0]]; !exists {
+				incompatible[pair[0]] = make(map[string]bool, 0)
+			}
+			incompatible[pair[0]][pair[1]] = true
+		}
+	}
+
+	// the smallest load limit is enough to tell if any route is closed to a load, without scanning every route for each train
+	lowestMaxLoad := 0
+	for _, routeSpec := range problem.Routes {
+		if routeSpec.MaxLoad > 0 && (lowestMaxLoad == 0 || routeSpec.MaxLoad < lowestMaxLoad) {
+			lowestMaxLoad = routeSpec.MaxLoad
+		}
+	}
+
+	return &Graph{
+		Stations:       stations,
+		StationNames:   stationNamesMap,
+		Routes:         routes,
+		Deliveries:     deliveries,
+		Trains:         trains,
+		Moves:          make([]Move, 0),
+		PriorityWeight: StrictPriority,
+		Incompatible:   incompatible,
+		occupations:    make(map[string][]routeOccupation, 0),
+		lowestMaxLoad:  lowestMaxLoad,
+	}, nil
+}
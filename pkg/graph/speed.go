@@ -0,0 +1,77 @@
+package graph
+
+import (
+	"math"
+	"slices"
+)
+
+// NormalSpeed is the speed factor of a train travelling the routes in their given travel times
+const NormalSpeed = 1.0
+
+// TravelTimeAt returns the whole minutes a train with the given speed factor takes to travel the route, limited by the maximum speed of the route
+func (route Route) TravelTimeAt(speed float64) int {
+	if route.MaxSpeed > 0 && speed > route.MaxSpeed {
+		speed = route.MaxSpeed
+	}
+	// allow for floating point error, e.g. 30 / 1.2 is 25.000000000000004
+	return max(1, int(math.Ceil(float64(route.TravelTime)/speed-1e-9)))
+}
+
+// travelMatrices holds the shortest travel times and paths between every station for trains of one speed factor
+type travelMatrices struct {
+	TravelTime map[StationId]map[StationId]int
+	TravelPath map[StationId]map[StationId]StationId
+}
+
+// trainSpeeds returns the distinct speed factors of the trains, sorted from slowest to fastest
+func (g *Graph) trainSpeeds() []float64 {
+	speeds := make([]float64, 0)
+	for _, train := range g.Trains {
+		if !slices.Contains(speeds, train.Speed) {
+			speeds = append(speeds, train.Speed)
+		}
+	}
+	slices.Sort(speeds)
+	return speeds
+}
+
+// fastestRouteAt returns the fastest of the routes connecting 2 adjacent stations for a train with the given speed factor, or nil if they are not connected
+// If parallel routes have the same travel time, the one defined first is chosen
+func (g *Graph) fastestRouteAt(startingStationId StationId, endingStationId StationId, speed float64) *Route {
+	var fastestRoute *Route
+	for _, route := range g.Routes[startingStationId][endingStationId] {
+		if fastestRoute == nil || route.TravelTimeAt(speed) < fastestRoute.TravelTimeAt(speed) {
+			fastestRoute = route
+		}
+	}
+	return fastestRoute
+}
+
+// matricesFor returns the travel matrices of the speed class of a train, falling back to the normal speed ones if they were not built
+func (g *Graph) matricesFor(trainName string) travelMatrices {
+	if train, exists := g.Trains[trainName]; exists {
+		if matrices, exists := g.speedMatrices[train.Speed]; exists {
+			return matrices
+		}
+	}
+	return travelMatrices{TravelTime: g.TravelTimeMatrix, TravelPath: g.TravelPathMatrix}
+}
+
+// travelTime returns the shortest travel time of a train between 2 stations at its speed and current load, MaxInt if the ending station cannot be reached
+func (g *Graph) travelTime(trainName string, startingStationId StationId, endingStationId StationId) int {
+	if train, exists := g.Trains[trainName]; exists && g.limitsLoad(train.Load()) {
+		_, travelTime := g.loadLimitedPath(train.Speed, train.Load(), startingStationId, endingStationId)
+		return travelTime
+	}
+	return g.matricesFor(trainName).TravelTime[startingStationId][endingStationId]
+}
+
+// shortestPathFor returns the backtracked fastest path of a train between 2 stations at its speed and current load, or nil if the ending station cannot be reached
+// Routes limiting the speed of fast trains or the load of heavy trains can make the fastest path differ from the one of GetShortestPath
+func (g *Graph) shortestPathFor(trainName string, startingStationId StationId, endingStationId StationId) []StationId {
+	if train, exists := g.Trains[trainName]; exists && g.limitsLoad(train.Load()) {
+		paths, _ := g.loadLimitedPath(train.Speed, train.Load(), startingStationId, endingStationId)
+		return paths
+	}
+	return backtrackPath(g.matricesFor(trainName), startingStationId, endingStationId)
+}
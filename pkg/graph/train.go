@@ -11,6 +11,20 @@ type Train struct {
 	TravelTime       int
 	CurrentStationId StationId
 	PackagesCarried  []Package
+	CouplingTime     int       // minutes taken to couple or uncouple wagons whenever packages are loaded or unloaded
+	Speed            float64   // factor the travel times of the routes are divided by, trains with the same factor form a speed class
+	VolumeCapacity   int       // volume in litres of packages the train can hold in total, 0 if unlimited
+	DepotStationId   StationId // station the train returns to once every package is delivered, NoDepot if it has none
+	DutyLimit        int       // minutes the crew can work continuously before a rest break, 0 if unlimited
+	ShiftLimit       int       // minutes the crew can work in total during its shift, 0 if unlimited
+	RestTime         int       // minutes a rest break lasts
+	DutyTime         int       // minutes the crew has worked so far during its shift
+	ContinuousDuty   int       // minutes the crew has worked since its last rest break
+}
+
+// HasDepot checks if the train has to return to a depot once every package is delivered
+func (train *Train) HasDepot() bool {
+	return train.DepotStationId != NoDepot
 }
 
 // Adds a package to the train
@@ -19,11 +33,51 @@ func (train *Train) AddPackage(delivery Package) {
 	train.Capacity = train.Capacity - delivery.Weight
 }
 
+// Returns the total weight of the packages the train is carrying
+func (train *Train) Load() int {
+	load := 0
+	for _, carriedPackage := range train.PackagesCarried {
+		load += carriedPackage.Weight
+	}
+	return load
+}
+
+// Returns the total volume of the packages the train is carrying
+func (train *Train) Volume() int {
+	volume := 0
+	for _, carriedPackage := range train.PackagesCarried {
+		volume += carriedPackage.Volume
+	}
+	return volume
+}
+
+// Returns the total weight the train can carry, Capacity only holds what is left of it
+func (train *Train) TotalCapacity() int {
+	return train.Capacity + train.Load()
+}
+
+// Checks if the package fits in the train along with the packages it is already carrying, both by weight and by volume
+func (train *Train) CanFit(delivery Package) bool {
+	if delivery.Weight > train.Capacity {
+		return false
+	}
+	return train.VolumeCapacity == 0 || train.Volume()+delivery.Volume <= train.VolumeCapacity
+}
+
 // Updates the train's current station
 func (train *Train) UpdatePosition(newStationId StationId) {
 	train.CurrentStationId = newStationId
 }
 
+// Waits at the current station until the given time, if it has not passed yet
+func (train *Train) WaitUntil(time int) {
+	// a wait at least as long as a rest break gives the crew its break
+	if time-train.TravelTime >= train.RestTime {
+		train.ContinuousDuty = 0
+	}
+	train.TravelTime = max(train.TravelTime, time)
+}
+
 // Drops a package if possible at its current station location
 // If it cannot drop any packages, an empty slice is returned
 func (train *Train) DropPackages() []Package {
@@ -0,0 +1,143 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// quantityUnit is an accepted suffix of a quantity and its value in the unit used internally
+type quantityUnit struct {
+	Suffix string
+	Value  float64
+}
+
+// weightUnits maps the accepted weight suffixes to their value in kilograms, which is the unit used internally
+var weightUnits = []quantityUnit{
+	{Suffix: "kg", Value: 1},
+	{Suffix: "t", Value: 1000},
+}
+
+// volumeUnits maps the accepted volume suffixes to their value in litres, which is the unit used internally
+var volumeUnits = []quantityUnit{
+	{Suffix: "m3", Value: 1000},
+	{Suffix: "l", Value: 1},
+}
+
+// ParseWeight parses a weight or capacity into kilograms, e.g. 500kg or 2.5t, a bare number is already in kilograms
+func ParseWeight(raw string) (int, error) {
+	return parseQuantity(raw, weightUnits, "weight, e.g. 500kg or 2.5t", "kilograms")
+}
+
+// ParseVolume parses a volume or volume capacity into litres, e.g. 500l or 2.5m3, a bare number is already in litres
+func ParseVolume(raw string) (int, error) {
+	return parseQuantity(raw, volumeUnits, "volume, e.g. 500l or 2.5m3", "litres")
+}
+
+// parseQuantity parses a whole quantity with an optional unit suffix into the unit used internally, which a bare number is already in
+func parseQuantity(raw string, units []quantityUnit, what string, baseUnit string) (int, error) {
+	value := strings.TrimSpace(raw)
+	multiplier := 1.0
+	for _, unit := range units {
+		if strings.HasSuffix(value, unit.Suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.Suffix))
+			multiplier = unit.Value
+			break
+		}
+	}
+	if multiplier == 1 {
+		if amount, err := strconv.Atoi(value); err == nil {
+			return amount, nil
+		}
+	}
+
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return 0, fmt.Errorf("%q is not a valid %s", raw, what)
+	}
+	// allow for floating point error, e.g. 2.3 * 1000 is 2299.9999999999995
+	quantity := amount * multiplier
+	if math.Abs(quantity-math.Round(quantity)) > 1e-6 {
+		return 0, fmt.Errorf("%q is not a whole number of %s", raw, baseUnit)
+	}
+	return int(math.Round(quantity)), nil
+}
+
+// ParseTravelTime parses a travel time, or a time since the start of the plan such as a due time, into minutes, e.g. 90m or 1h30m, a bare number is already in minutes
+func ParseTravelTime(raw string) (int, error) {
+	value := strings.TrimSpace(raw)
+	if minutes, err := strconv.Atoi(value); err == nil {
+		return minutes, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a valid duration, e.g. 90 or 1h30m", raw)
+	}
+	if duration%time.Minute != 0 {
+		return 0, fmt.Errorf("%q is not a whole number of minutes", raw)
+	}
+	return int(duration / time.Minute), nil
+}
+
+// ParseHandlingRate parses the time taken to handle a kilogram of packages into minutes per kilogram
+// The rate can be given per kilogram or per tonne, e.g. 0.5 or 30s/kg, or 2m/t, a bare number is in minutes per kilogram
+func ParseHandlingRate(raw string) (float64, error) {
+	value := strings.TrimSpace(raw)
+	perKilograms := 1.0
+	if amount, unit, hasUnit := strings.Cut(value, "/"); hasUnit {
+		found := false
+		for _, weightUnit := range weightUnits {
+			if strings.TrimSpace(unit) == weightUnit.Suffix {
+				perKilograms = weightUnit.Value
+				found = true
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("%q is not a valid handling rate, the time must be per kg or per t, e.g. 30s/kg or 2m/t", raw)
+		}
+		value = strings.TrimSpace(amount)
+	}
+
+	minutes, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(minutes) || math.IsInf(minutes, 0) {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a valid handling rate, e.g. 0.5, 30s/kg or 2m/t", raw)
+		}
+		minutes = duration.Minutes()
+	}
+	return minutes / perKilograms, nil
+}
+
+// ParseClock parses a wall clock time such as 06:00 into minutes after midnight
+func ParseClock(raw string) (int, error) {
+	clock, err := time.Parse("15:04", strings.TrimSpace(raw))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a valid clock time, e.g. 06:00", raw)
+	}
+	return clock.Hour()*60 + clock.Minute(), nil
+}
+
+// FormatClock formats the wall clock time reached after the given number of minutes from a start time in minutes after midnight
+// Times on the following days are suffixed with the number of days passed, e.g. 01:30+1
+func FormatClock(startClock int, minutes int) string {
+	total := startClock + minutes
+	clock := fmt.Sprintf("%02d:%02d", (total/60)%24, total%60)
+	if days := total / (24 * 60); days > 0 {
+		clock += fmt.Sprintf("+%d", days)
+	}
+	return clock
+}
+
+// ParseSpeed parses the speed factor of a train, e.g. 1.5 or 1.5x for a train travelling 1.5 times as fast as the travel times of the routes
+func ParseSpeed(raw string) (float64, error) {
+	value := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(raw), "x"))
+	speed, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(speed) || math.IsInf(speed, 0) {
+		return 0, fmt.Errorf("%q is not a valid speed factor, e.g. 1.5 or 0.75x", raw)
+	}
+	return speed, nil
+}
@@ -0,0 +1,318 @@
+package graph
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// InputSection names one of the sections of the raw input
+type InputSection string
+
+const (
+	StationSection InputSection = "station"
+	RouteSection   InputSection = "route"
+	PackageSection InputSection = "package"
+	TrainSection   InputSection = "train"
+
+	// IncompatibilitySection lists pairs of package categories that must not be carried by a train at the same time
+	IncompatibilitySection InputSection = "incompatibility"
+)
+
+// inputSections lists the sections in the order they appear in the raw input
+var inputSections = []InputSection{StationSection, RouteSection, PackageSection, TrainSection, IncompatibilitySection}
+
+// sectionFields lists the comma separated fields of an entry in each section, in the order they appear in the raw input
+var sectionFields = map[InputSection][]string{
+	StationSection: {"name"},
+	RouteSection:   {"name", "from", "to", "travel time", "direction"},
+	PackageSection: {"name", "weight", "from", "to"},
+	TrainSection:   {"name", "capacity", "start"},
+
+	IncompatibilitySection: {"category", "other category"},
+}
+
+// sectionRequiredFields is the number of leading fields that every entry of a section must have, the rest are optional
+var sectionRequiredFields = map[InputSection]int{
+	StationSection: 1,
+	RouteSection:   4,
+	PackageSection: 4,
+	TrainSection:   3,
+
+	IncompatibilitySection: 2,
+}
+
+// sectionAttributes lists the optional key=value attributes that can follow the fields of an entry in each section, e.g. K1,3,A,E,due=2h
+var sectionAttributes = map[InputSection][]string{
+	StationSection: {"handling", "handling-rate", "platforms"},
+	RouteSection:   {"capacity", "track", "max-speed", "max-load", "closed"},
+	PackageSection: {"ready", "due", "priority", "volume", "category"},
+	TrainSection:   {"coupling", "speed", "volume", "depot", "duty", "shift", "rest"},
+}
+
+// InputIssue describes a single problem found while validating the raw input or a Problem
+type InputIssue struct {
+	Section   InputSection
+	Index     int    // position of the entry within its section
+	Field     int    // index of the offending comma separated field, -1 if the issue concerns the whole entry
+	Attribute string // key of the offending key=value attribute, empty if the issue does not concern an attribute
+	Message   string
+}
+
+func (issue InputIssue) Error() string {
+	if issue.Attribute != "" {
+		return fmt.Sprintf("%s #%d (%s): %s", issue.Section, issue.Index+1, issue.Attribute, issue.Message)
+	}
+	if issue.Field < 0 || issue.Field >= len(sectionFields[issue.Section]) {
+		return fmt.Sprintf("%s #%d: %s", issue.Section, issue.Index+1, issue.Message)
+	}
+	return fmt.Sprintf("%s #%d (%s): %s", issue.Section, issue.Index+1, sectionFields[issue.Section][issue.Field], issue.Message)
+}
+
+// inputValidator collects issues for the input and keeps track of names seen so far
+type inputValidator struct {
+	issues       []InputIssue
+	stationNames map[StationName]bool
+}
+
+func newInputValidator() *inputValidator {
+	return &inputValidator{
+		issues:       make([]InputIssue, 0),
+		stationNames: make(map[StationName]bool, 0),
+	}
+}
+
+func (v *inputValidator) report(section InputSection, index int, field int, format string, args ...any) {
+	v.issues = append(v.issues, InputIssue{
+		Section: section,
+		Index:   index,
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// reportAttribute reports an issue with a key=value attribute, the field can be -1 if its position in the raw input is not known
+func (v *inputValidator) reportAttribute(section InputSection, index int, field int, attribute string, format string, args ...any) {
+	v.issues = append(v.issues, InputIssue{
+		Section:   section,
+		Index:     index,
+		Field:     field,
+		Attribute: attribute,
+		Message:   fmt.Sprintf(format, args...),
+	})
+}
+
+// checkName reports empty and duplicated names within a section
+func (v *inputValidator) checkName(section InputSection, index int, field int, name string, seen map[string]int) {
+	if name == "" {
+		v.report(section, index, field, "%s name is empty", section)
+		return
+	}
+	if firstIndex, exists := seen[name]; exists {
+		v.report(section, index, field, "duplicate %s name %q, already defined by %s #%d", section, name, section, firstIndex+1)
+		return
+	}
+	seen[name] = index
+}
+
+// checkStation reports references to stations that do not exist
+func (v *inputValidator) checkStation(section InputSection, index int, field int, name string) {
+	if !v.stationNames[name] {
+		v.report(section, index, field, "unknown station %q", name)
+	}
+}
+
+// checkPositive reports values that are not positive
+func (v *inputValidator) checkPositive(section InputSection, index int, field int, what string, value int) {
+	if value <= 0 {
+		v.report(section, index, field, "%s must be greater than 0, found %d", what, value)
+	}
+}
+
+/*
+ValidateProblem checks a problem definition and returns every issue found, instead of stopping at the first one
+It rejects duplicated names, references to unknown stations, non-positive travel times, weights and capacities and impossible time windows
+The Field of each issue refers to the position of the value in the raw input format, see sectionFields
+*/
+func ValidateProblem(problem Problem) []InputIssue {
+	v := newInputValidator()
+
+	seenStations := make(map[string]int, 0)
+	for i, station := range problem.Stations {
+		v.checkName(StationSection, i, 0, station.Name, seenStations)
+		if strings.Contains(station.Name, ",") {
+			v.report(StationSection, i, 0, "station name %q must not contain a comma", station.Name)
+		}
+		v.stationNames[station.Name] = true
+		if station.HandlingTime < 0 {
+			v.reportAttribute(StationSection, i, -1, "handling", "handling time must not be negative, found %d", station.HandlingTime)
+		}
+		if station.HandlingRate < 0 {
+			v.reportAttribute(StationSection, i, -1, "handling-rate", "handling rate must not be negative, found %g", station.HandlingRate)
+		}
+		if station.Platforms < 0 {
+			v.reportAttribute(StationSection, i, -1, "platforms", "number of platforms must not be negative, found %d", station.Platforms)
+		}
+	}
+
+	seenRoutes := make(map[string]int, 0)
+	for i, route := range problem.Routes {
+		v.checkName(RouteSection, i, 0, route.Name, seenRoutes)
+		v.checkStation(RouteSection, i, 1, route.From)
+		v.checkStation(RouteSection, i, 2, route.To)
+		v.checkPositive(RouteSection, i, 3, "travel time", route.TravelTime)
+		if route.Capacity < 0 {
+			v.reportAttribute(RouteSection, i, -1, "capacity", "capacity must not be negative, found %d", route.Capacity)
+		}
+		if route.MaxSpeed < 0 {
+			v.reportAttribute(RouteSection, i, -1, "max-speed", "maximum speed factor must not be negative, found %g", route.MaxSpeed)
+		}
+		if route.MaxLoad < 0 {
+			v.reportAttribute(RouteSection, i, -1, "max-load", "maximum load must not be negative, found %d", route.MaxLoad)
+		}
+	}
+
+	seenPackages := make(map[string]int, 0)
+	for i, delivery := range problem.Packages {
+		v.checkName(PackageSection, i, 0, delivery.Name, seenPackages)
+		v.checkPositive(PackageSection, i, 1, "weight", delivery.Weight)
+		v.checkStation(PackageSection, i, 2, delivery.From)
+		v.checkStation(PackageSection, i, 3, delivery.To)
+		if delivery.ReadyTime < 0 {
+			v.reportAttribute(PackageSection, i, -1, "ready", "ready time must not be negative, found %d", delivery.ReadyTime)
+		}
+		if delivery.DueTime < 0 {
+			v.reportAttribute(PackageSection, i, -1, "due", "due time must not be negative, found %d", delivery.DueTime)
+		} else if delivery.DueTime > 0 && delivery.DueTime < delivery.ReadyTime {
+			v.reportAttribute(PackageSection, i, -1, "due", "due time %d is before the ready time %d", delivery.DueTime, delivery.ReadyTime)
+		}
+		if !delivery.Priority.IsValid() {
+			v.reportAttribute(PackageSection, i, -1, "priority", "unknown priority class %d", int(delivery.Priority))
+		}
+		if delivery.Volume < 0 {
+			v.reportAttribute(PackageSection, i, -1, "volume", "volume must not be negative, found %d", delivery.Volume)
+		}
+		if strings.Contains(delivery.Category, ",") {
+			v.reportAttribute(PackageSection, i, -1, "category", "category %q must not contain a comma", delivery.Category)
+		}
+	}
+
+	platforms := make(map[StationName]int, 0)
+	for _, station := range problem.Stations {
+		platforms[station.Name] = station.Platforms
+	}
+	seenTrains := make(map[string]int, 0)
+	trainsAtStart := make(map[StationName]int, 0)
+	for i, train := range problem.Trains {
+		v.checkName(TrainSection, i, 0, train.Name, seenTrains)
+		v.checkPositive(TrainSection, i, 1, "capacity", train.Capacity)
+		v.checkStation(TrainSection, i, 2, train.Start)
+		trainsAtStart[train.Start]++
+		if platforms[train.Start] > 0 && trainsAtStart[train.Start] > platforms[train.Start] {
+			v.report(TrainSection, i, 2, "station %q has %d platforms, which are all taken by the trains starting there before this one", train.Start, platforms[train.Start])
+		}
+		if train.CouplingTime < 0 {
+			v.reportAttribute(TrainSection, i, -1, "coupling", "coupling time must not be negative, found %d", train.CouplingTime)
+		}
+		if train.Speed < 0 {
+			v.reportAttribute(TrainSection, i, -1, "speed", "speed factor must not be negative, found %g", train.Speed)
+		}
+		if train.Volume < 0 {
+			v.reportAttribute(TrainSection, i, -1, "volume", "volume capacity must not be negative, found %d", train.Volume)
+		}
+		if train.DutyLimit < 0 {
+			v.reportAttribute(TrainSection, i, -1, "duty", "duty limit must not be negative, found %d", train.DutyLimit)
+		}
+		if train.ShiftLimit < 0 {
+			v.reportAttribute(TrainSection, i, -1, "shift", "shift limit must not be negative, found %d", train.ShiftLimit)
+		}
+		if train.RestTime < 0 {
+			v.reportAttribute(TrainSection, i, -1, "rest", "rest time must not be negative, found %d", train.RestTime)
+		} else if train.RestTime > 0 && train.DutyLimit == 0 {
+			v.reportAttribute(TrainSection, i, -1, "rest", "rest time is only taken by crews with a duty limit")
+		}
+		if train.Depot != "" && !v.stationNames[train.Depot] {
+			v.reportAttribute(TrainSection, i, -1, "depot", "unknown depot station %q", train.Depot)
+		}
+	}
+
+	seenPairs := make(map[[2]string]int, 0)
+	for i, incompatibility := range problem.Incompatibilities {
+		if incompatibility.Category == "" {
+			v.report(IncompatibilitySection, i, 0, "category is empty")
+		}
+		if incompatibility.OtherCategory == "" {
+			v.report(IncompatibilitySection, i, 1, "category is empty")
+		}
+		// the pair is the same in either order
+		pair := [2]string{incompatibility.Category, incompatibility.OtherCategory}
+		if pair[0] > pair[1] {
+			pair[0], pair[1] = pair[1], pair[0]
+		}
+		if firstIndex, exists := seenPairs[pair]; exists {
+			v.report(IncompatibilitySection, i, -1, "categories %q and %q are already listed as incompatible by %s #%d", incompatibility.Category, incompatibility.OtherCategory, IncompatibilitySection, firstIndex+1)
+			continue
+		}
+		seenPairs[pair] = i
+	}
+
+	return v.issues
+}
+
+/*
+ValidateInput checks the raw input strings accepted by NewGraph and returns every issue found, sorted by section and entry
+Malformed entries are reported by ParseProblem, the remaining issues by ValidateProblem
+A value that could not be parsed is not validated again, so each mistake is only reported once
+*/
+func ValidateInput(rawStations []string, rawRoutes []string, rawDeliveries []string, rawTrains []string, rawIncompatibilities []string) []InputIssue {
+	problem, issues := ParseProblem(rawStations, rawRoutes, rawDeliveries, rawTrains, rawIncompatibilities)
+	rawEntries := map[InputSection][]string{
+		StationSection: rawStations,
+		RouteSection:   rawRoutes,
+		PackageSection: rawDeliveries,
+		TrainSection:   rawTrains,
+
+		IncompatibilitySection: rawIncompatibilities,
+	}
+
+	type fieldKey struct {
+		section InputSection
+		index   int
+		field   int
+	}
+	malformed := make(map[fieldKey]bool, len(issues))
+	for _, issue := range issues {
+		malformed[fieldKey{issue.Section, issue.Index, issue.Field}] = true
+	}
+	for _, issue := range ValidateProblem(problem) {
+		// point attribute issues at the field the attribute was given in
+		if issue.Attribute != "" && issue.Field < 0 {
+			issue.Field = attributeField(rawEntries[issue.Section][issue.Index], issue.Attribute)
+		}
+		if malformed[fieldKey{issue.Section, issue.Index, -1}] || malformed[fieldKey{issue.Section, issue.Index, issue.Field}] {
+			continue
+		}
+		issues = append(issues, issue)
+	}
+
+	slices.SortStableFunc(issues, func(a InputIssue, b InputIssue) int {
+		if a.Section != b.Section {
+			return slices.Index(inputSections, a.Section) - slices.Index(inputSections, b.Section)
+		}
+		if a.Index != b.Index {
+			return a.Index - b.Index
+		}
+		return a.Field - b.Field
+	})
+	return issues
+}
+
+// attributeField returns the index of the comma separated field of a raw entry holding the given attribute, or -1 if it is not given
+func attributeField(raw string, attribute string) int {
+	for i, field := range strings.Split(raw, ",") {
+		if key, _, isAttribute := strings.Cut(field, "="); isAttribute && strings.TrimSpace(key) == attribute {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,252 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+)
+
+// rawInput holds the raw input strings of every section accepted by ValidateInput
+type rawInput struct {
+	stations          []string
+	routes            []string
+	packages          []string
+	trains            []string
+	incompatibilities []string
+}
+
+// validInput returns a small input without any issue, which each test case changes a section of
+func validInput() rawInput {
+	return rawInput{
+		stations:          []string{"A", "B", "C"},
+		routes:            []string{"E1,A,B,10", "E2,B,C,20"},
+		packages:          []string{"K1,5,A,C"},
+		trains:            []string{"Q1,10,B"},
+		incompatibilities: []string{},
+	}
+}
+
+// expectedIssue describes an issue ValidateInput should report, the message only has to contain the given text
+type expectedIssue struct {
+	section   InputSection
+	index     int
+	field     int
+	attribute string
+	message   string
+}
+
+func TestValidateInput(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(input *rawInput)
+		issues []expectedIssue
+	}{
+		{
+			name:   "valid input",
+			change: func(input *rawInput) {},
+			issues: nil,
+		},
+		{
+			name: "unknown station in a route",
+			change: func(input *rawInput) {
+				input.routes[1] = "E2,B,Z,20"
+			},
+			issues: []expectedIssue{{RouteSection, 1, 2, "", `unknown station "Z"`}},
+		},
+		{
+			name: "unknown stations in a package",
+			change: func(input *rawInput) {
+				input.packages[0] = "K1,5,Y,Z"
+			},
+			issues: []expectedIssue{
+				{PackageSection, 0, 2, "", `unknown station "Y"`},
+				{PackageSection, 0, 3, "", `unknown station "Z"`},
+			},
+		},
+		{
+			name: "unknown starting station of a train",
+			change: func(input *rawInput) {
+				input.trains[0] = "Q1,10,Z"
+			},
+			issues: []expectedIssue{{TrainSection, 0, 2, "", `unknown station "Z"`}},
+		},
+		{
+			name: "unknown depot of a train",
+			change: func(input *rawInput) {
+				input.trains[0] = "Q1,10,B,depot=Z"
+			},
+			issues: []expectedIssue{{TrainSection, 0, 3, "depot", `unknown depot station "Z"`}},
+		},
+		{
+			name: "duplicate station",
+			change: func(input *rawInput) {
+				input.stations = append(input.stations, "A")
+			},
+			issues: []expectedIssue{{StationSection, 3, 0, "", `duplicate station name "A", already defined by station #1`}},
+		},
+		{
+			name: "duplicate route",
+			change: func(input *rawInput) {
+				input.routes[1] = "E1,B,C,20"
+			},
+			issues: []expectedIssue{{RouteSection, 1, 0, "", `duplicate route name "E1", already defined by route #1`}},
+		},
+		{
+			name: "duplicate package",
+			change: func(input *rawInput) {
+				input.packages = append(input.packages, "K1,2,B,C")
+			},
+			issues: []expectedIssue{{PackageSection, 1, 0, "", `duplicate package name "K1", already defined by package #1`}},
+		},
+		{
+			name: "duplicate train",
+			change: func(input *rawInput) {
+				input.trains = append(input.trains, "Q1,5,A")
+			},
+			issues: []expectedIssue{{TrainSection, 1, 0, "", `duplicate train name "Q1", already defined by train #1`}},
+		},
+		{
+			name: "duplicate incompatibility in the other order",
+			change: func(input *rawInput) {
+				input.incompatibilities = []string{"hazmat,food", "food,hazmat"}
+			},
+			issues: []expectedIssue{{IncompatibilitySection, 1, -1, "", "already listed as incompatible by incompatibility #1"}},
+		},
+		{
+			name: "empty station name",
+			change: func(input *rawInput) {
+				input.stations = append(input.stations, "")
+			},
+			issues: []expectedIssue{{StationSection, 3, 0, "", "station name is empty"}},
+		},
+		{
+			name: "non-positive travel time",
+			change: func(input *rawInput) {
+				input.routes[0] = "E1,A,B,0"
+			},
+			issues: []expectedIssue{{RouteSection, 0, 3, "", "travel time must be greater than 0, found 0"}},
+		},
+		{
+			name: "invalid travel time",
+			change: func(input *rawInput) {
+				input.routes[0] = "E1,A,B,soon"
+			},
+			issues: []expectedIssue{{RouteSection, 0, 3, "", `"soon" is not a valid duration`}},
+		},
+		{
+			name: "non-positive weight",
+			change: func(input *rawInput) {
+				input.packages[0] = "K1,0,A,C"
+			},
+			issues: []expectedIssue{{PackageSection, 0, 1, "", "weight must be greater than 0, found 0"}},
+		},
+		{
+			name: "negative weight",
+			change: func(input *rawInput) {
+				input.packages[0] = "K1,-3,A,C"
+			},
+			issues: []expectedIssue{{PackageSection, 0, 1, "", "weight must be greater than 0, found -3"}},
+		},
+		{
+			name: "non-positive capacity",
+			change: func(input *rawInput) {
+				input.trains[0] = "Q1,0,B"
+			},
+			issues: []expectedIssue{{TrainSection, 0, 1, "", "capacity must be greater than 0, found 0"}},
+		},
+		{
+			name: "too few route fields",
+			change: func(input *rawInput) {
+				input.routes[0] = "E1,A,B"
+			},
+			issues: []expectedIssue{{RouteSection, 0, -1, "", "expected 4 to 5 fields (name,from,to,travel time[,direction]), found 3"}},
+		},
+		{
+			name: "too many package fields",
+			change: func(input *rawInput) {
+				input.packages[0] = "K1,5,A,C,D"
+			},
+			issues: []expectedIssue{{PackageSection, 0, -1, "", "expected 4 fields (name,weight,from,to), found 5"}},
+		},
+		{
+			name: "too few train fields",
+			change: func(input *rawInput) {
+				input.trains[0] = "Q1,10"
+			},
+			issues: []expectedIssue{{TrainSection, 0, -1, "", "expected 3 fields (name,capacity,start), found 2"}},
+		},
+		{
+			name: "too few incompatibility fields",
+			change: func(input *rawInput) {
+				input.incompatibilities = []string{"hazmat"}
+			},
+			issues: []expectedIssue{{IncompatibilitySection, 0, -1, "", "expected 2 fields (category,other category), found 1"}},
+		},
+		{
+			name: "invalid direction",
+			change: func(input *rawInput) {
+				input.routes[0] = "E1,A,B,10,<-"
+			},
+			issues: []expectedIssue{{RouteSection, 0, 4, "", `direction must be -> or <->, found "<-"`}},
+		},
+		{
+			name: "unknown attribute",
+			change: func(input *rawInput) {
+				input.packages[0] = "K1,5,A,C,colour=red"
+			},
+			issues: []expectedIssue{{PackageSection, 0, 4, "colour", `unknown attribute "colour"`}},
+		},
+		{
+			name: "field after an attribute",
+			change: func(input *rawInput) {
+				input.routes[0] = "E1,A,B,capacity=1,10"
+			},
+			issues: []expectedIssue{
+				{RouteSection, 0, 4, "", `field "10" must come before the key=value attributes`},
+			},
+		},
+		{
+			name: "due time before the ready time",
+			change: func(input *rawInput) {
+				input.packages[0] = "K1,5,A,C,ready=2h,due=1h"
+			},
+			issues: []expectedIssue{{PackageSection, 0, 5, "due", "due time 60 is before the ready time 120"}},
+		},
+		{
+			name: "every issue is reported at once, sorted by section and entry",
+			change: func(input *rawInput) {
+				input.stations = append(input.stations, "B")
+				input.routes[1] = "E2,B,Z,20"
+				input.packages[0] = "K1,0,A,C"
+				input.trains[0] = "Q1,0,Y"
+			},
+			issues: []expectedIssue{
+				{StationSection, 3, 0, "", `duplicate station name "B"`},
+				{RouteSection, 1, 2, "", `unknown station "Z"`},
+				{PackageSection, 0, 1, "", "weight must be greater than 0"},
+				{TrainSection, 0, 1, "", "capacity must be greater than 0"},
+				{TrainSection, 0, 2, "", `unknown station "Y"`},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := validInput()
+			test.change(&input)
+			issues := ValidateInput(input.stations, input.routes, input.packages, input.trains, input.incompatibilities)
+
+			if len(issues) != len(test.issues) {
+				t.Fatalf("expected %d issues, found %d: %v", len(test.issues), len(issues), issues)
+			}
+			for i, expected := range test.issues {
+				issue := issues[i]
+				if issue.Section != expected.section || issue.Index != expected.index || issue.Field != expected.field || issue.Attribute != expected.attribute {
+					t.Errorf("issue %d: expected %s #%d field %d attribute %q, found %s #%d field %d attribute %q", i, expected.section, expected.index, expected.field, expected.attribute, issue.Section, issue.Index, issue.Field, issue.Attribute)
+				}
+				if !strings.Contains(issue.Message, expected.message) {
+					t.Errorf("issue %d: expected the message to contain %q, found %q", i, expected.message, issue.Message)
+				}
+			}
+		})
+	}
+}